@@ -0,0 +1,45 @@
+package tplx
+
+import "maps"
+
+// mergeAnnotations merges the annotations of metas, with later fragments
+// taking precedence.
+func mergeAnnotations(metas []Meta) map[string]string {
+	var m map[string]string
+	for _, meta := range metas {
+		if len(meta.Annotations) == 0 {
+			continue
+		}
+		if m == nil {
+			m = make(map[string]string)
+		}
+		maps.Copy(m, meta.Annotations)
+	}
+	return m
+}
+
+// Annotations returns the annotations of the top-level template name, merged
+// from all of its fragments, including those of extended templates. If
+// fragments share a key, the one merged last wins, just like blocks. The
+// annotations are captured when the template is parsed, and the returned map
+// is a copy.
+//
+// Returns ErrUnknownTemplate if the template does not exist.
+func (r *renderer) Annotations(name string) (map[string]string, error) {
+	e, ok := r.lookup(name)
+	if !ok {
+		return nil, ErrUnknownTemplate
+	}
+	return maps.Clone(e.annotations), nil
+}
+
+// HasAnnotation reports whether the top-level template name has an annotation
+// with the given key.
+func (r *renderer) HasAnnotation(name, key string) bool {
+	e, ok := r.lookup(name)
+	if !ok {
+		return false
+	}
+	_, ok = e.annotations[key]
+	return ok
+}
@@ -0,0 +1,103 @@
+package tplx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AuditEntry records a single successful render.
+//
+// TemplateName is the name of the rendered top-level template. UserID is the
+// user on whose behalf the template was rendered, if known. Timestamp is the
+// time of the render in RFC 3339 format. DataHash is the hex-encoded SHA-256
+// hash of the JSON encoding of the data.
+type AuditEntry struct {
+	TemplateName string
+	UserID       string
+	Timestamp    string
+	DataHash     string
+}
+
+// AuditLogger receives an entry for every successful render.
+type AuditLogger interface {
+	Log(ctx context.Context, entry AuditEntry)
+}
+
+// WithAuditLog calls logger after each successful render. The user ID of each
+// entry is taken from the render context using the key set by
+// WithAuditUserKey.
+func WithAuditLog(logger AuditLogger) Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, func(next RenderHandler) RenderHandler {
+			return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+				err := next(ctx, w, name, data, funcs)
+				if err != nil {
+					return err
+				}
+
+				var userID string
+				if c.auditUserKey != nil {
+					if v := ctx.Value(c.auditUserKey); v != nil {
+						userID = fmt.Sprint(v)
+					}
+				}
+
+				logger.Log(ctx, AuditEntry{
+					TemplateName: name,
+					UserID:       userID,
+					Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
+					DataHash:     hashData(data),
+				})
+				return nil
+			}
+		})
+	}
+}
+
+// WithAuditUserKey sets the context key under which the user ID recorded by
+// WithAuditLog is stored. The value is formatted with fmt.Sprint.
+func WithAuditUserKey(key any) Option {
+	return func(c *config) {
+		c.auditUserKey = key
+	}
+}
+
+// hashData returns the hex-encoded SHA-256 hash of the JSON encoding of data,
+// falling back to its default format if it cannot be encoded.
+func hashData(data any) string {
+	b, err := json.Marshal(data)
+	if err != nil {
+		b = fmt.Appendf(nil, "%#v", data)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// MemAuditLogger is an AuditLogger that keeps all entries in memory, which is
+// mostly useful for tests. The zero value is ready to use.
+type MemAuditLogger struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+// Log appends entry to the logger.
+func (l *MemAuditLogger) Log(_ context.Context, entry AuditEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+// Entries returns a copy of all logged entries in order.
+func (l *MemAuditLogger) Entries() []AuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.entries)
+}
@@ -0,0 +1,129 @@
+package tplx
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"reflect"
+)
+
+// WithBaseData merges base, which must be a struct or a pointer to one, into
+// the data of every render, so that common fields such as the user session or
+// navigation state do not have to be populated by every handler.
+//
+// Fields of base act as defaults: a field of the data is set to the base
+// field of the same name only if it is the zero value. Nested structs are
+// merged field by field, and the fields of embedded structs in the data are
+// matched against base as if they were top-level fields, including fields
+// that base itself promotes from embedded structs. Fields whose types are not
+// assignable are left alone.
+//
+// Struct and pointer-to-struct data is copied before merging, so the caller's
+// value is not modified. For data of a map type with string keys, exported
+// fields of base are added under their names if the key is missing. Nil data
+// is replaced by base, and other data is passed through unchanged.
+func WithBaseData(base any) Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, func(next RenderHandler) RenderHandler {
+			return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+				return next(ctx, w, name, mergeBase(base, data), funcs)
+			}
+		})
+	}
+}
+
+func mergeBase(base, data any) any {
+	if data == nil {
+		return base
+	}
+
+	bv := reflect.ValueOf(base)
+	for bv.Kind() == reflect.Pointer {
+		if bv.IsNil() {
+			return data
+		}
+		bv = bv.Elem()
+	}
+	if bv.Kind() != reflect.Struct {
+		return data
+	}
+
+	dv := reflect.ValueOf(data)
+	switch {
+	case dv.Kind() == reflect.Struct:
+		out := reflect.New(dv.Type()).Elem()
+		out.Set(dv)
+		mergeStruct(out, bv)
+		return out.Interface()
+
+	case dv.Kind() == reflect.Pointer && !dv.IsNil() && dv.Elem().Kind() == reflect.Struct:
+		out := reflect.New(dv.Elem().Type())
+		out.Elem().Set(dv.Elem())
+		mergeStruct(out.Elem(), bv)
+		return out.Interface()
+
+	case dv.Kind() == reflect.Map && dv.Type().Key().Kind() == reflect.String:
+		out := reflect.MakeMapWithSize(dv.Type(), dv.Len())
+		iter := dv.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), iter.Value())
+		}
+
+		for _, f := range reflect.VisibleFields(bv.Type()) {
+			if !f.IsExported() || f.Anonymous {
+				continue
+			}
+
+			key := reflect.ValueOf(f.Name).Convert(dv.Type().Key())
+			fv, err := bv.FieldByIndexErr(f.Index)
+			if err != nil || out.MapIndex(key).IsValid() {
+				continue
+			}
+			if fv.Type().AssignableTo(dv.Type().Elem()) {
+				out.SetMapIndex(key, fv)
+			}
+		}
+		return out.Interface()
+	}
+
+	return data
+}
+
+// mergeStruct sets zero fields of dst to the fields of the same name in base.
+// Fields of base promoted through a nil embedded pointer are skipped.
+func mergeStruct(dst, base reflect.Value) {
+	baseFields := make(map[string][]int)
+	for _, f := range reflect.VisibleFields(base.Type()) {
+		baseFields[f.Name] = f.Index
+	}
+
+	t := dst.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		df := dst.Field(i)
+		if !df.CanSet() {
+			continue
+		}
+
+		if f.Anonymous && df.Kind() == reflect.Struct {
+			mergeStruct(df, base)
+			continue
+		}
+
+		index, ok := baseFields[f.Name]
+		if !ok {
+			continue
+		}
+		bf, err := base.FieldByIndexErr(index)
+		if err != nil || !bf.CanInterface() {
+			continue
+		}
+
+		switch {
+		case df.IsZero() && bf.Type().AssignableTo(df.Type()):
+			df.Set(bf)
+		case df.Kind() == reflect.Struct && bf.Kind() == reflect.Struct:
+			mergeStruct(df, bf)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package tplx
+
+import (
+	"context"
+	"html/template"
+	"io"
+)
+
+// BlockRenderer is implemented by renderers that can render individual blocks
+// of a top-level template.
+type BlockRenderer interface {
+	RenderBlock(w io.Writer, topLevel, block string, data any, funcs template.FuncMap) error
+}
+
+// RenderBlock writes the rendered output of a single named block within a
+// top-level template to the provided writer, without executing the top-level
+// template itself. This is useful for fragment-based responses such as those
+// requested by HTMX.
+//
+// The topLevel parameter specifies the top-level template whose set contains
+// the block. The block parameter specifies the name of the sub-template to
+// execute. The data and funcs parameters are the same as for Render. The
+// block is rendered through the same pipeline as the top-level template, so
+// middlewares, output limits and timeouts apply to it, with topLevel as the
+// template name they see.
+//
+// Returns ErrUnknownTemplate if either template does not exist, or an error if
+// the block cannot be rendered.
+func (r *renderer) RenderBlock(w io.Writer, topLevel, block string, data any, funcs template.FuncMap) error {
+	return r.RenderBlockContext(context.Background(), w, topLevel, block, data, funcs)
+}
+
+// RenderBlockContext is like RenderBlock but carries a context through the
+// render pipeline, as RenderContext does.
+func (r *renderer) RenderBlockContext(ctx context.Context, w io.Writer, topLevel, block string, data any, funcs template.FuncMap) error {
+	topLevel = r.localize(ctx, topLevel)
+	ctx = r.negotiateXHTML(ctx, w, topLevel)
+	return r.render(context.WithValue(ctx, blockKey{}, block), w, topLevel, data, funcs)
+}
+
+type blockKey struct{}
+
+// blockFromContext returns the block to execute instead of the top-level
+// template, recorded by RenderBlockContext.
+func blockFromContext(ctx context.Context) string {
+	block, _ := ctx.Value(blockKey{}).(string)
+	return block
+}
+
+// executeBlock executes block within the set of the top-level template
+// directly, bypassing the render pipeline.
+func (r *renderer) executeBlock(w io.Writer, topLevel, block string, data any, funcs template.FuncMap) error {
+	e, ok := r.lookup(topLevel)
+	if !ok || e.base.Lookup(block) == nil {
+		return ErrUnknownTemplate
+	}
+	return r.executeTemplate(context.Background(), e, w, topLevel, block, data, funcs)
+}
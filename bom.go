@@ -0,0 +1,38 @@
+package tplx
+
+import (
+	"bytes"
+
+	"golang.org/x/text/encoding/unicode"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// StripBOM returns text without its leading UTF-8 byte order mark, if any.
+func StripBOM(text []byte) []byte {
+	return bytes.TrimPrefix(text, bomUTF8)
+}
+
+// decodeBOM converts text to UTF-8 according to its byte order mark and
+// reports whether it has one. UTF-8 marks are stripped, and text starting with
+// a UTF-16 mark is decoded.
+func decodeBOM(text []byte) ([]byte, bool, error) {
+	var endianness unicode.Endianness
+	switch {
+	case bytes.HasPrefix(text, bomUTF8):
+		return StripBOM(text), true, nil
+	case bytes.HasPrefix(text, bomUTF16LE):
+		endianness = unicode.LittleEndian
+	case bytes.HasPrefix(text, bomUTF16BE):
+		endianness = unicode.BigEndian
+	default:
+		return text, false, nil
+	}
+
+	decoded, err := unicode.UTF16(endianness, unicode.ExpectBOM).NewDecoder().Bytes(text)
+	return decoded, true, err
+}
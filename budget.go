@@ -0,0 +1,62 @@
+package tplx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+)
+
+// ErrCPUBudgetExceeded is returned when a render uses up the budget set by
+// WithCPUBudget.
+var ErrCPUBudgetExceeded = errors.New("template render budget exceeded")
+
+// WithCPUBudget aborts renders that write more than maxInstructions bytes, as
+// a defense in depth against adversarial data or templates producing
+// exponentially large output, e.g. through recursive includes. Despite its
+// name, it is an output budget: Go offers no way to measure the CPU time of a
+// single render, so the work is approximated by the output, each written byte
+// being one unit. Templates that loop without writing are not stopped by it.
+//
+// The budget is enforced by a LimitedWriter like WithOutputLimit, but once it
+// is used up, the context of the render is also canceled with
+// ErrCPUBudgetExceeded as its cause, so that template functions receiving it
+// through WithContextInjection stop as well, and the render fails with
+// ErrCPUBudgetExceeded. A budget of 0 or less disables the check.
+func WithCPUBudget(maxInstructions int64) Option {
+	return func(c *config) {
+		if maxInstructions <= 0 {
+			return
+		}
+		c.middlewares = append(c.middlewares, func(next RenderHandler) RenderHandler {
+			return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+				ctx, cancel := context.WithCancelCause(ctx)
+				defer cancel(nil)
+
+				lw := LimitWriter(w, maxInstructions)
+				err := next(ctx, budgetWriter{lw: lw, cancel: cancel}, name, data, funcs)
+				if lw.Written > lw.Limit {
+					return fmt.Errorf("%w: %q wrote more than %d bytes", ErrCPUBudgetExceeded, name, maxInstructions)
+				}
+				return err
+			}
+		})
+	}
+}
+
+// budgetWriter cancels a render once its LimitedWriter runs out of budget.
+type budgetWriter struct {
+	lw     *LimitedWriter
+	cancel context.CancelCauseFunc
+}
+
+func (bw budgetWriter) Write(p []byte) (int, error) {
+	n, err := bw.lw.Write(p)
+	var limitErr *OutputLimitError
+	if errors.As(err, &limitErr) {
+		bw.cancel(ErrCPUBudgetExceeded)
+		return n, ErrCPUBudgetExceeded
+	}
+	return n, err
+}
@@ -0,0 +1,53 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestWithCPUBudget(t *testing.T) {
+	fsys := fstest.MapFS{"list.html": {Data: []byte(`{{range .}}{{.}}{{end}}`)}}
+	spec := Spec{"list": {{Name: "list", Path: "list.html"}}}
+
+	// The inner middleware records the context the budget passes down.
+	var renderCtx context.Context
+	record := Chain(func(next RenderHandler) RenderHandler {
+		return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+			renderCtx = ctx
+			return next(ctx, w, name, data, funcs)
+		}
+	})
+	r, err := NewRenderer(fsys, spec, nil, WithCPUBudget(10), record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := r.Render(&buf, "list", []string{"abcde", "fghij"}, nil); err != nil {
+		t.Fatalf("within budget: %v", err)
+	}
+	if got, want := buf.String(), "abcdefghij"; got != want {
+		t.Errorf("within budget: got %q, want %q", got, want)
+	}
+	if err := context.Cause(renderCtx); !errors.Is(err, context.Canceled) {
+		t.Errorf("within budget: got cause %v, want context.Canceled after the render", err)
+	}
+
+	buf.Reset()
+	err = r.Render(&buf, "list", strings.Split("abcdefghijklmnop", ""), nil)
+	if !errors.Is(err, ErrCPUBudgetExceeded) {
+		t.Fatalf("over budget: got %v, want ErrCPUBudgetExceeded", err)
+	}
+	if buf.Len() > 10 {
+		t.Errorf("over budget: wrote %d bytes, want at most 10", buf.Len())
+	}
+	if err := context.Cause(renderCtx); !errors.Is(err, ErrCPUBudgetExceeded) {
+		t.Errorf("over budget: got cause %v, want ErrCPUBudgetExceeded", err)
+	}
+}
@@ -0,0 +1,138 @@
+package tplx
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"sync"
+)
+
+// BufferGrowthStrategy determines how render buffers grow when the output
+// does not fit.
+type BufferGrowthStrategy int
+
+const (
+	// BufferDoubling doubles the capacity, like bytes.Buffer.
+	BufferDoubling BufferGrowthStrategy = iota
+
+	// BufferLinear grows the capacity in steps of the initial size.
+	BufferLinear
+
+	// BufferFixed grows the capacity to exactly the size needed.
+	BufferFixed
+)
+
+// bufferGrowth configures the render buffers of WithBufferPool.
+type bufferGrowth struct {
+	initial  int
+	max      int
+	strategy BufferGrowthStrategy
+	hints    map[string]int
+}
+
+// WithBufferPool renders into a buffer taken from a pool and copies it to the
+// writer only once the render has succeeded, so that a failing render writes
+// nothing. Reusing buffers keeps allocations low for frequently rendered
+// templates. See WithBufferGrowth for tuning the buffers.
+func WithBufferPool(enabled bool) Option {
+	return func(c *config) {
+		c.bufferPool = enabled
+	}
+}
+
+// WithBufferGrowth controls the allocation of the render buffers, and enables
+// buffering as with WithBufferPool(true). New buffers start with a capacity of
+// initialSize bytes and grow according to strategy. Buffers that have grown
+// beyond maxSize bytes are dropped after use instead of being returned to the
+// pool, so that a single huge render does not pin its memory; a maxSize of 0
+// or less keeps all buffers.
+func WithBufferGrowth(initialSize, maxSize int, strategy BufferGrowthStrategy) Option {
+	return func(c *config) {
+		c.bufferPool = true
+		c.bufferGrowth.initial = max(initialSize, 0)
+		c.bufferGrowth.max = maxSize
+		c.bufferGrowth.strategy = strategy
+	}
+}
+
+// WithTemplateOutputHint pre-sizes the render buffer for the top-level
+// template name to sizeHint bytes, for templates with a known approximate
+// output size. It only has an effect if buffering is enabled with
+// WithBufferPool or WithBufferGrowth.
+func WithTemplateOutputHint(name string, sizeHint int) Option {
+	return func(c *config) {
+		if c.bufferGrowth.hints == nil {
+			c.bufferGrowth.hints = make(map[string]int)
+		}
+		c.bufferGrowth.hints[name] = sizeHint
+	}
+}
+
+// renderBuffer is a growable byte buffer following a BufferGrowthStrategy.
+type renderBuffer struct {
+	b []byte
+	g *bufferGrowth
+}
+
+func (rb *renderBuffer) Write(p []byte) (int, error) {
+	rb.grow(len(p))
+	rb.b = append(rb.b, p...)
+	return len(p), nil
+}
+
+// grow ensures that n more bytes fit into the buffer.
+func (rb *renderBuffer) grow(n int) {
+	need := len(rb.b) + n
+	if need <= cap(rb.b) {
+		return
+	}
+
+	size := cap(rb.b)
+	switch rb.g.strategy {
+	case BufferLinear:
+		step := max(rb.g.initial, 512)
+		for size < need {
+			size += step
+		}
+	case BufferFixed:
+		size = need
+	default:
+		size = max(size, 64)
+		for size < need {
+			size *= 2
+		}
+	}
+
+	b := make([]byte, len(rb.b), size)
+	copy(b, rb.b)
+	rb.b = b
+}
+
+func bufferRender(next RenderHandler, g *bufferGrowth) RenderHandler {
+	pool := sync.Pool{
+		New: func() any {
+			return &renderBuffer{b: make([]byte, 0, g.initial), g: g}
+		},
+	}
+
+	return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+		buf := pool.Get().(*renderBuffer)
+		defer func() {
+			if g.max <= 0 || cap(buf.b) <= g.max {
+				buf.b = buf.b[:0]
+				pool.Put(buf)
+			}
+		}()
+
+		if hint, ok := g.hints[name]; ok {
+			buf.grow(hint)
+		}
+
+		if err := next(ctx, buf, name, data, funcs); err != nil {
+			return err
+		}
+
+		_, err := w.Write(buf.b)
+		return err
+	}
+}
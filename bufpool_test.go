@@ -0,0 +1,123 @@
+package tplx
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// newListRenderer returns a renderer for a template producing about 8 KiB of
+// output from a list of 200 items.
+func newListRenderer(b *testing.B, opts ...Option) (Renderer, []string) {
+	b.Helper()
+	fsys := fstest.MapFS{"list.html": {Data: []byte(`<ul>{{range .}}<li class="item">{{.}}</li>{{end}}</ul>`)}}
+	r, err := NewRenderer(fsys, Spec{"list": {{Name: "list", Path: "list.html"}}}, nil, opts...)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	items := make([]string, 200)
+	for i := range items {
+		items[i] = strings.Repeat("x", 16)
+	}
+	return r, items
+}
+
+// BenchmarkBufferPool compares buffering renders with the pool of
+// WithBufferPool and WithBufferGrowth to rendering into a new bytes.Buffer
+// for each render, which is what callers do without the option.
+func BenchmarkBufferPool(b *testing.B) {
+	b.Run("bytes.Buffer", func(b *testing.B) {
+		r, items := newListRenderer(b)
+		b.ReportAllocs()
+		for range b.N {
+			var buf bytes.Buffer
+			if err := r.Render(&buf, "list", items, nil); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := buf.WriteTo(io.Discard); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	for _, bc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"pool", []Option{WithBufferPool(true)}},
+		{"pool/doubling", []Option{WithBufferGrowth(1024, 0, BufferDoubling)}},
+		{"pool/linear", []Option{WithBufferGrowth(1024, 0, BufferLinear)}},
+		{"pool/fixed", []Option{WithBufferGrowth(1024, 0, BufferFixed)}},
+		{"pool/hint", []Option{WithBufferPool(true), WithTemplateOutputHint("list", 8192)}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			r, items := newListRenderer(b, bc.opts...)
+			b.ReportAllocs()
+			for range b.N {
+				if err := r.Render(io.Discard, "list", items, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkBufferPoolParallel(b *testing.B) {
+	b.Run("bytes.Buffer", func(b *testing.B) {
+		r, items := newListRenderer(b)
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				var buf bytes.Buffer
+				if err := r.Render(&buf, "list", items, nil); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := buf.WriteTo(io.Discard); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+
+	b.Run("pool", func(b *testing.B) {
+		r, items := newListRenderer(b, WithBufferPool(true))
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if err := r.Render(io.Discard, "list", items, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+}
+
+// BenchmarkRenderString compares RenderString, which collects the output in
+// a strings.Builder, to rendering into a bytes.Buffer and converting it to a
+// string.
+func BenchmarkRenderString(b *testing.B) {
+	b.Run("bytes.Buffer", func(b *testing.B) {
+		r, items := newListRenderer(b)
+		b.ReportAllocs()
+		for range b.N {
+			var buf bytes.Buffer
+			if err := r.Render(&buf, "list", items, nil); err != nil {
+				b.Fatal(err)
+			}
+			_ = buf.String()
+		}
+	})
+
+	b.Run("RenderString", func(b *testing.B) {
+		r, items := newListRenderer(b)
+		b.ReportAllocs()
+		for range b.N {
+			if _, err := RenderString(r, "list", items, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package tplx
+
+import (
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"maps"
+)
+
+// RendererBuilder constructs a Renderer step by step, as an alternative to
+// passing options to NewRenderer when the configuration is assembled across
+// many conditions:
+//
+//	b := tplx.NewRendererBuilder().SetFS(fsys).SetSpec(spec)
+//	if dev {
+//		b.AddOption(tplx.DevelopmentDefaults())
+//	}
+//	r, err := b.Build()
+//
+// All methods return the builder for chaining. The zero value is ready to
+// use.
+type RendererBuilder struct {
+	fsys  fs.FS
+	spec  Spec
+	funcs template.FuncMap
+	opts  []Option
+}
+
+// NewRendererBuilder creates an empty RendererBuilder.
+func NewRendererBuilder() *RendererBuilder {
+	return &RendererBuilder{}
+}
+
+// SetFS sets the file system templates are loaded from.
+func (b *RendererBuilder) SetFS(fsys fs.FS) *RendererBuilder {
+	b.fsys = fsys
+	return b
+}
+
+// SetSpec sets the template specification.
+func (b *RendererBuilder) SetSpec(spec Spec) *RendererBuilder {
+	b.spec = spec
+	return b
+}
+
+// AddFuncs adds global template functions. Functions added later replace
+// earlier ones of the same name.
+func (b *RendererBuilder) AddFuncs(funcs template.FuncMap) *RendererBuilder {
+	if b.funcs == nil {
+		b.funcs = make(template.FuncMap, len(funcs))
+	}
+	maps.Copy(b.funcs, funcs)
+	return b
+}
+
+// SetDelims sets the action delimiters, like WithDelims.
+func (b *RendererBuilder) SetDelims(left, right string) *RendererBuilder {
+	return b.AddOption(WithDelims(left, right))
+}
+
+// EnableMissingKeyError makes indexing a map with a missing key an error,
+// like WithMissingKey("error").
+func (b *RendererBuilder) EnableMissingKeyError() *RendererBuilder {
+	return b.AddOption(WithMissingKey("error"))
+}
+
+// SetLogger sets the logger, like WithLogger.
+func (b *RendererBuilder) SetLogger(logger *slog.Logger) *RendererBuilder {
+	return b.AddOption(WithLogger(logger))
+}
+
+// SetDebug sets debug mode, like WithDebug.
+func (b *RendererBuilder) SetDebug(debug bool) *RendererBuilder {
+	return b.AddOption(WithDebug(debug))
+}
+
+// AddOption adds an arbitrary option. Options are applied in the order they
+// are added, including those added by the other methods.
+func (b *RendererBuilder) AddOption(opts ...Option) *RendererBuilder {
+	b.opts = append(b.opts, opts...)
+	return b
+}
+
+// Build creates the Renderer with NewRenderer.
+//
+// Returns a Renderer instance or an error as described for NewRenderer.
+func (b *RendererBuilder) Build() (Renderer, error) {
+	return NewRenderer(b.fsys, b.spec, b.funcs, b.opts...)
+}
+
+// MustBuild is like Build but panics if the Renderer cannot be created.
+func (b *RendererBuilder) MustBuild() Renderer {
+	r, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
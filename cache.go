@@ -0,0 +1,342 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is the time for which CachingMiddleware caches responses.
+const DefaultCacheTTL = 5 * time.Minute
+
+// Cache stores rendered responses for CachingMiddleware.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// MemoryCache is an in-memory Cache. The zero value is ready to use. Expired
+// entries are removed when they are looked up.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache creates an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{}
+}
+
+// Get returns the value stored under key, unless it has expired.
+func (mc *MemoryCache) Get(key string) ([]byte, bool) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	e, ok := mc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(e.expires) {
+		delete(mc.entries, key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Set stores value under key for the duration ttl.
+func (mc *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if mc.entries == nil {
+		mc.entries = make(map[string]memoryCacheEntry)
+	}
+	mc.entries[key] = memoryCacheEntry{value: value, expires: time.Now().Add(ttl)}
+}
+
+// cachedResponse is a response stored by CachingMiddleware. TemplateETag is
+// the ETag of the template when the response was rendered, and ETag the tag
+// sent to clients, which also covers the data. Vary and CacheControl hold the
+// values of the respective response headers.
+type cachedResponse struct {
+	Template     string   `json:"template"`
+	TemplateETag string   `json:"template_etag"`
+	ETag         string   `json:"etag"`
+	Vary         []string `json:"vary,omitempty"`
+	CacheControl string   `json:"cache_control,omitempty"`
+	ContentType  string   `json:"content_type"`
+	Body         []byte   `json:"body"`
+}
+
+// writeHeaders sets the headers of cr shared by full and 304 responses.
+func (cr *cachedResponse) writeHeaders(h http.Header) {
+	h.Set("ETag", cr.ETag)
+	for _, v := range cr.Vary {
+		h.Add("Vary", v)
+	}
+	if cr.CacheControl != "" {
+		h.Set("Cache-Control", cr.CacheControl)
+	}
+}
+
+// CacheOption configures CachingMiddleware and CachedRenderer.
+type CacheOption func(*cacheConfig)
+
+type cacheConfig struct {
+	key func(req *http.Request) (string, bool)
+	ttl time.Duration
+}
+
+// WithCacheKey sets the function computing the cache key of a request, which
+// must identify everything the response depends on, such as the user a
+// response was rendered for. Requests for which fn returns false are neither
+// served from nor stored in the cache. The default key is the URL, and
+// requests carrying a Cookie or Authorization header are not cached, since
+// their responses may contain session data such as CSRF tokens or flash
+// messages.
+func WithCacheKey(fn func(req *http.Request) (key string, ok bool)) CacheOption {
+	return func(c *cacheConfig) {
+		c.key = fn
+	}
+}
+
+// WithCacheTTL sets the time for which responses are cached. A ttl of 0 or
+// less selects DefaultCacheTTL.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *cacheConfig) {
+		c.ttl = ttl
+	}
+}
+
+// defaultCacheKey is the default key function of CachingMiddleware.
+func defaultCacheKey(req *http.Request) (string, bool) {
+	if req.Header.Get("Cookie") != "" || req.Header.Get("Authorization") != "" {
+		return "", false
+	}
+	return req.URL.String(), true
+}
+
+// CachingMiddleware returns a middleware that caches the responses of GET
+// requests in cache, by default keyed by URL for DefaultCacheTTL; see
+// WithCacheKey and WithCacheTTL. Responses carry an ETag derived from the
+// ETag of the template, as returned by the ETag method of r, and the JSON
+// encoding of the render data.
+//
+// The middleware stores a renderer in the request context (see NewContext) to
+// learn which template a handler renders, so handlers must render with the
+// renderer from the context, e.g. through RenderResult. Only successful
+// responses that rendered exactly one template with data that can be encoded
+// as JSON are cached. Responses setting cookies, marked private or no-store
+// by Cache-Control, or varying on all headers are not cached; the headers
+// named by Vary become part of the key. A cached response is served without
+// calling the handler as long as its template has not changed since, with
+// the ETag, Vary, Cache-Control and Content-Type headers of the original
+// response, answering with 304 Not Modified if the If-None-Match header of
+// the request matches the ETag.
+//
+// If r has no ETag method, the middleware only stores r in the context.
+func CachingMiddleware(r Renderer, cache Cache, opts ...CacheOption) func(http.Handler) http.Handler {
+	tagger, ok := r.(interface{ ETag(string) (string, error) })
+
+	c := cacheConfig{key: defaultCacheKey, ttl: DefaultCacheTTL}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.ttl <= 0 {
+		c.ttl = DefaultCacheTTL
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !ok || req.Method != http.MethodGet {
+				next.ServeHTTP(w, req.WithContext(NewContext(req.Context(), r)))
+				return
+			}
+
+			base, cacheable := c.key(req)
+			if !cacheable {
+				next.ServeHTTP(w, req.WithContext(NewContext(req.Context(), r)))
+				return
+			}
+
+			if b, ok := cache.Get(varyKey(cache, base, req)); ok {
+				var cr cachedResponse
+				if json.Unmarshal(b, &cr) == nil {
+					if etag, err := tagger.ETag(cr.Template); err == nil && etag == cr.TemplateETag {
+						cr.writeHeaders(w.Header())
+						if etagMatches(req.Header.Get("If-None-Match"), cr.ETag) {
+							w.WriteHeader(http.StatusNotModified)
+							return
+						}
+
+						w.Header().Set("Content-Type", cr.ContentType)
+						_, _ = w.Write(cr.Body)
+						return
+					}
+				}
+			}
+
+			rec := &cacheRecorder{ResponseWriter: w, tagger: tagger}
+			rr := &recordingRenderer{r: r, rec: rec}
+			next.ServeHTTP(rec, req.WithContext(NewContext(req.Context(), rr)))
+
+			if rec.status != http.StatusOK || rec.renders != 1 || rec.etag == "" || !storable(w.Header()) {
+				return
+			}
+
+			h := w.Header()
+			vary := varyHeaders(h)
+			cache.Set(varyIndexKey(base), []byte(strings.Join(vary, ",")), c.ttl)
+
+			etag := h.Get("ETag")
+			if etag == "" {
+				etag = rec.etag
+			}
+			b, err := json.Marshal(cachedResponse{
+				Template:     rec.name,
+				TemplateETag: rec.templateETag,
+				ETag:         etag,
+				Vary:         h.Values("Vary"),
+				CacheControl: h.Get("Cache-Control"),
+				ContentType:  h.Get("Content-Type"),
+				Body:         rec.body.Bytes(),
+			})
+			if err == nil {
+				cache.Set(headersKey(base, vary, req), b, c.ttl)
+			}
+		})
+	}
+}
+
+// storable reports whether a response with the header h may be shared
+// through the cache.
+func storable(h http.Header) bool {
+	if h.Get("Set-Cookie") != "" {
+		return false
+	}
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "private", "no-store":
+			return false
+		}
+	}
+	return !slices.Contains(varyHeaders(h), "*")
+}
+
+// varyHeaders returns the canonical names of the headers listed by the Vary
+// header of h.
+func varyHeaders(h http.Header) []string {
+	var names []string
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+func varyIndexKey(base string) string {
+	return "vary\x00" + base
+}
+
+// varyKey returns the key of the cached response for req, using the Vary
+// headers recorded for base.
+func varyKey(cache Cache, base string, req *http.Request) string {
+	var vary []string
+	if b, ok := cache.Get(varyIndexKey(base)); ok && len(b) > 0 {
+		vary = strings.Split(string(b), ",")
+	}
+	return headersKey(base, vary, req)
+}
+
+// headersKey appends the values of the headers vary of req to base.
+func headersKey(base string, vary []string, req *http.Request) string {
+	var sb strings.Builder
+	sb.WriteString("response\x00")
+	sb.WriteString(base)
+	for _, name := range vary {
+		fmt.Fprintf(&sb, "\x00%s=%q", name, req.Header.Values(name))
+	}
+	return sb.String()
+}
+
+// etagMatches reports whether the If-None-Match header value header matches
+// etag.
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheRecorder passes a response through while recording it.
+type cacheRecorder struct {
+	http.ResponseWriter
+	tagger       interface{ ETag(string) (string, error) }
+	status       int
+	body         bytes.Buffer
+	name         string
+	templateETag string
+	etag         string
+	renders      int
+}
+
+func (cr *cacheRecorder) WriteHeader(code int) {
+	if cr.status == 0 {
+		cr.status = code
+	}
+	cr.ResponseWriter.WriteHeader(code)
+}
+
+func (cr *cacheRecorder) Write(p []byte) (int, error) {
+	if cr.status == 0 {
+		cr.status = http.StatusOK
+	}
+	cr.body.Write(p)
+	return cr.ResponseWriter.Write(p)
+}
+
+// recordingRenderer records the templates rendered through it, setting the
+// ETag header of the response for them.
+type recordingRenderer struct {
+	r   Renderer
+	rec *cacheRecorder
+}
+
+func (rr *recordingRenderer) Render(w io.Writer, name string, data any, funcs template.FuncMap) error {
+	return rr.RenderContext(context.Background(), w, name, data, funcs)
+}
+
+func (rr *recordingRenderer) RenderContext(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+	rr.rec.renders++
+	rr.rec.name = name
+	rr.rec.templateETag, rr.rec.etag = "", ""
+	if tag, err := rr.rec.tagger.ETag(name); err == nil {
+		if etag := dataETag(ctx, rr.r, name, data); etag != "" {
+			rr.rec.templateETag, rr.rec.etag = tag, etag
+			if rr.rec.status == 0 {
+				rr.rec.Header().Set("ETag", etag)
+			}
+		}
+	}
+	return RenderContext(ctx, rr.r, w, name, data, funcs)
+}
@@ -0,0 +1,126 @@
+package tplx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// cachingHandler renders the page template with the renderer from the
+// request context, counting its calls.
+type cachingHandler struct {
+	calls   int
+	headers map[string]string
+}
+
+func (h *cachingHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.calls++
+	for k, v := range h.headers {
+		w.Header().Set(k, v)
+	}
+	r, _ := FromContext(req.Context())
+	if err := RenderContext(NewRequestContext(req.Context(), req), r, w, "page", req.Header.Get("Accept-Language"), nil); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func newCachingHandler(tb testing.TB, headers map[string]string) (*renderer, *cachingHandler, http.Handler) {
+	tb.Helper()
+	r := newPageRenderer(tb)
+	h := &cachingHandler{headers: headers}
+	return r, h, CachingMiddleware(r, NewMemoryCache())(h)
+}
+
+func get(h http.Handler, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/page", nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCachingMiddlewareHit(t *testing.T) {
+	_, h, mw := newCachingHandler(t, map[string]string{
+		"Content-Type":  "text/html; charset=utf-8",
+		"Cache-Control": "public, max-age=60",
+		"Vary":          "Accept-Language",
+	})
+
+	miss := get(mw, nil)
+	hit := get(mw, nil)
+	if h.calls != 1 {
+		t.Fatalf("handler called %d times, want 1", h.calls)
+	}
+	if got, want := hit.Body.String(), miss.Body.String(); got != want {
+		t.Errorf("body: got %q, want %q", got, want)
+	}
+	for _, name := range []string{"ETag", "Vary", "Cache-Control", "Content-Type"} {
+		got, want := hit.Header().Get(name), miss.Header().Get(name)
+		if want == "" {
+			t.Errorf("%s: not set on the original response", name)
+		}
+		if got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+
+	notModified := get(mw, map[string]string{"If-None-Match": miss.Header().Get("ETag")})
+	if notModified.Code != http.StatusNotModified || notModified.Body.Len() != 0 {
+		t.Errorf("revalidation: got %d with body %q, want 304 without body", notModified.Code, notModified.Body.String())
+	}
+	if got := notModified.Header().Get("Vary"); got != "Accept-Language" {
+		t.Errorf("revalidation Vary: got %q, want Accept-Language", got)
+	}
+
+	// The Vary header makes the language part of the key.
+	if got, want := get(mw, map[string]string{"Accept-Language": "fr"}).Body.String(), "<main><h1>fr</h1></main>"; got != want {
+		t.Errorf("other language: got %q, want %q", got, want)
+	}
+	if h.calls != 2 {
+		t.Errorf("handler called %d times, want 2", h.calls)
+	}
+}
+
+func TestCachingMiddlewareBypass(t *testing.T) {
+	tests := []struct {
+		name    string
+		request map[string]string
+		headers map[string]string
+	}{
+		{name: "cookie", request: map[string]string{"Cookie": "session=1"}},
+		{name: "authorization", request: map[string]string{"Authorization": "Bearer x"}},
+		{name: "set-cookie", headers: map[string]string{"Set-Cookie": "session=1"}},
+		{name: "private", headers: map[string]string{"Cache-Control": "private"}},
+		{name: "vary all", headers: map[string]string{"Vary": "*"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, h, mw := newCachingHandler(t, tt.headers)
+			for range 2 {
+				if rec := get(mw, tt.request); rec.Code != http.StatusOK {
+					t.Fatalf("got status %d", rec.Code)
+				}
+			}
+			if h.calls != 2 {
+				t.Errorf("handler called %d times, want 2", h.calls)
+			}
+		})
+	}
+}
+
+func TestCachingMiddlewareTemplateChange(t *testing.T) {
+	r, h, mw := newCachingHandler(t, nil)
+	get(mw, nil)
+
+	if err := r.PatchSubTemplate("page", "content", "<h2>{{.}}</h2>"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := get(mw, nil).Body.String(), "<main><h2></h2></main>"; got != want {
+		t.Errorf("after patch: got %q, want %q", got, want)
+	}
+	if h.calls != 2 {
+		t.Errorf("handler called %d times, want 2", h.calls)
+	}
+}
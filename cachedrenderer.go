@@ -0,0 +1,141 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sync"
+	"time"
+)
+
+// CachedRenderer is a Renderer that caches the output of another renderer,
+// so that identical renders are only performed once. Output is looked up in
+// the cache by a hash of the template name, the ETag of the template if the
+// underlying renderer reports one, and the JSON encoding of the data; on a
+// miss, the template is rendered, the output stored and then written. Since
+// Reload and PatchSubTemplate change the ETag, they also change the keys of
+// the template, so that outdated output is not served.
+//
+// Renders passing funcs, and renders whose data cannot be encoded as JSON,
+// bypass the cache, since their output cannot be identified by the key.
+//
+// Options such as WithCSRFTokenKey or WithFlashExtractor make the output depend
+// on the request in the render context (see NewRequestContext). For renders
+// with a request, the key function set with WithCacheKey is called and its
+// result added to the key; by default, it is the URL of the request, and
+// requests with a Cookie or Authorization header bypass the cache. Other
+// values of the context that the output depends on, such as a locale chosen
+// by WithLocaleSelector from something other than the request, are not part
+// of the key, so renderers using them must not be cached or must be given a
+// key function covering them.
+type CachedRenderer struct {
+	r     Renderer
+	cache Cache
+	c     cacheConfig
+
+	mu          sync.Mutex
+	generations map[string]uint64
+}
+
+// NewCachedRenderer creates a CachedRenderer rendering with r and storing
+// output in cache for the duration ttl, or DefaultCacheTTL if ttl is 0 or
+// less. The options are those of CachingMiddleware; WithCacheTTL overrides
+// ttl.
+func NewCachedRenderer(r Renderer, cache Cache, ttl time.Duration, opts ...CacheOption) *CachedRenderer {
+	c := cacheConfig{key: defaultCacheKey, ttl: ttl}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.ttl <= 0 {
+		c.ttl = DefaultCacheTTL
+	}
+	return &CachedRenderer{r: r, cache: cache, c: c, generations: make(map[string]uint64)}
+}
+
+// Render writes the output of the named template to w, from the cache if
+// possible.
+func (cr *CachedRenderer) Render(w io.Writer, name string, data any, funcs template.FuncMap) error {
+	return cr.RenderContext(context.Background(), w, name, data, funcs)
+}
+
+// RenderContext is like Render but carries a context through the render
+// pipeline of the underlying renderer.
+func (cr *CachedRenderer) RenderContext(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+	if len(funcs) > 0 {
+		return RenderContext(ctx, cr.r, w, name, data, funcs)
+	}
+
+	var reqKey string
+	if req, ok := RequestFromContext(ctx); ok {
+		if reqKey, ok = cr.c.key(req); !ok {
+			return RenderContext(ctx, cr.r, w, name, data, funcs)
+		}
+	}
+
+	key, ok := cr.key(name, reqKey, data)
+	if !ok {
+		return RenderContext(ctx, cr.r, w, name, data, funcs)
+	}
+
+	if b, ok := cr.cache.Get(key); ok {
+		_, err := w.Write(b)
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := RenderContext(ctx, cr.r, &buf, name, data, funcs); err != nil {
+		return err
+	}
+	cr.cache.Set(key, bytes.Clone(buf.Bytes()), cr.c.ttl)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// InvalidateCache evicts the cached output of the named template, e.g. after
+// the data it depends on has changed. Entries are evicted by changing the keys
+// of the template, so the old entries stay in the cache until they expire and
+// other CachedRenderer values sharing the cache are not affected.
+//
+// Returns ErrUnknownTemplate if the underlying renderer reports that it has no
+// template name.
+func (cr *CachedRenderer) InvalidateCache(name string) error {
+	if h, ok := cr.r.(interface{ Has(string) bool }); ok && !h.Has(name) {
+		return ErrUnknownTemplate
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.generations[name]++
+	return nil
+}
+
+// key returns the cache key of rendering name with data for the request with
+// the key reqKey, or false if the render cannot be cached.
+func (cr *CachedRenderer) key(name, reqKey string, data any) (string, bool) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", false
+	}
+
+	var etag string
+	if tagger, ok := cr.r.(interface{ ETag(string) (string, error) }); ok {
+		if etag, err = tagger.ETag(name); err != nil {
+			return "", false
+		}
+	}
+
+	cr.mu.Lock()
+	gen := cr.generations[name]
+	cr.mu.Unlock()
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%q %d %q %q\n", name, gen, etag, reqKey)
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), true
+}
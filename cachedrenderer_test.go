@@ -0,0 +1,157 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// countingRenderer counts the renders reaching the wrapped renderer.
+type countingRenderer struct {
+	*renderer
+	n atomic.Int64
+}
+
+func (cr *countingRenderer) Render(w io.Writer, name string, data any, funcs template.FuncMap) error {
+	return cr.RenderContext(context.Background(), w, name, data, funcs)
+}
+
+func (cr *countingRenderer) RenderContext(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+	cr.n.Add(1)
+	return cr.renderer.RenderContext(ctx, w, name, data, funcs)
+}
+
+func newCountingCachedRenderer(tb testing.TB) (*countingRenderer, *CachedRenderer) {
+	tb.Helper()
+	counter := &countingRenderer{renderer: newPageRenderer(tb)}
+	return counter, NewCachedRenderer(counter, NewMemoryCache(), 0)
+}
+
+func TestCachedRendererHitAndMiss(t *testing.T) {
+	counter, cr := newCountingCachedRenderer(t)
+
+	for _, data := range []string{"a", "a", "b", "a"} {
+		if got, want := renderString(t, cr, "page", data), "<main><h1>"+data+"</h1></main>"; got != want {
+			t.Errorf("%s: got %q, want %q", data, got, want)
+		}
+	}
+	if got := counter.n.Load(); got != 2 {
+		t.Errorf("got %d renders, want 2", got)
+	}
+
+	// Renders with funcs are never cached.
+	var buf bytes.Buffer
+	if err := cr.Render(&buf, "page", "a", template.FuncMap{"x": func() string { return "" }}); err != nil {
+		t.Fatal(err)
+	}
+	if got := counter.n.Load(); got != 3 {
+		t.Errorf("with funcs: got %d renders, want 3", got)
+	}
+}
+
+func TestCachedRendererInvalidation(t *testing.T) {
+	counter, cr := newCountingCachedRenderer(t)
+	renderString(t, cr, "page", "a")
+
+	if err := cr.InvalidateCache("page"); err != nil {
+		t.Fatal(err)
+	}
+	renderString(t, cr, "page", "a")
+	if got := counter.n.Load(); got != 2 {
+		t.Errorf("after InvalidateCache: got %d renders, want 2", got)
+	}
+
+	if err := counter.PatchSubTemplate("page", "content", "<h2>{{.}}</h2>"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := renderString(t, cr, "page", "a"), "<main><h2>a</h2></main>"; got != want {
+		t.Errorf("after PatchSubTemplate: got %q, want %q", got, want)
+	}
+
+	if err := cr.InvalidateCache("missing"); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("unknown template: got %v, want ErrUnknownTemplate", err)
+	}
+}
+
+func TestCachedRendererRequestKey(t *testing.T) {
+	counter, cr := newCountingCachedRenderer(t)
+
+	render := func(url, cookie string) {
+		t.Helper()
+		req := httptest.NewRequest("GET", url, nil)
+		if cookie != "" {
+			req.Header.Set("Cookie", cookie)
+		}
+		ctx := NewRequestContext(context.Background(), req)
+		if err := cr.RenderContext(ctx, io.Discard, "page", "a", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	render("/a", "")
+	render("/a", "")
+	render("/b", "")
+	render("/a", "session=1")
+	render("/a", "session=1")
+	if got := counter.n.Load(); got != 4 {
+		t.Errorf("got %d renders, want 4", got)
+	}
+}
+
+// TestCachedRendererConcurrent renders through the cache while the template
+// is patched, for the race detector.
+func TestCachedRendererConcurrent(t *testing.T) {
+	counter, cr := newCountingCachedRenderer(t)
+	valid := map[string]bool{
+		"<main><h1>x</h1></main>": true,
+		"<main><h2>x</h2></main>": true,
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				var buf bytes.Buffer
+				if err := cr.Render(&buf, "page", "x", nil); err != nil {
+					errs <- err
+					return
+				}
+				if !valid[buf.String()] {
+					errs <- fmt.Errorf("unexpected output %q", buf.String())
+					return
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 50 {
+			text := "<h1>{{.}}</h1>"
+			if i%2 == 0 {
+				text = "<h2>{{.}}</h2>"
+			}
+			if err := counter.PatchSubTemplate("page", "content", text); err != nil {
+				errs <- err
+				return
+			}
+			_ = cr.InvalidateCache("page")
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,50 @@
+package tplx
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// WithCharsetDetection converts template files that are not valid UTF-8 from
+// Windows-1252 to UTF-8 before they are parsed, for legacy templates stored
+// in Windows-1252 or Latin-1, which Windows-1252 extends. Files with an
+// explicit Meta.Encoding are always decoded with that encoding instead. The
+// files themselves are not modified.
+func WithCharsetDetection() Option {
+	return func(c *config) {
+		c.charsetDetection = true
+	}
+}
+
+// decodeText converts text from encoding to UTF-8. If encoding is empty, a
+// byte order mark determines the encoding, and if there is none and detect is
+// set, text that is not valid UTF-8 is decoded as Windows-1252.
+func decodeText(text []byte, encoding string, detect bool) ([]byte, error) {
+	if encoding != "" {
+		enc, err := htmlindex.Get(encoding)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown encoding %q", ErrInvalidSpec, encoding)
+		}
+
+		decoded, err := enc.NewDecoder().Bytes(text)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode template file from %s: %w", encoding, err)
+		}
+		return decoded, nil
+	}
+
+	if decoded, ok, err := decodeBOM(text); ok {
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode template file: %w", err)
+		}
+		return decoded, nil
+	}
+
+	if detect && !utf8.Valid(text) {
+		return charmap.Windows1252.NewDecoder().Bytes(text)
+	}
+	return text, nil
+}
@@ -0,0 +1,204 @@
+package tplx
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"io"
+	"sync"
+	texttemplate "text/template"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of rendering a template whose circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("template circuit breaker is open")
+
+// CircuitState is the state of the circuit breaker of a single template.
+type CircuitState int
+
+const (
+	// CircuitClosed means renders are attempted normally.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen means renders fail immediately with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen means a single probe render is allowed to decide
+	// whether the circuit closes again.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker stops rendering templates that fail too often, e.g. after a
+// bad deployment, so that no CPU is wasted on renders that are bound to fail.
+//
+// Each template has its own circuit. Only renders failing during template
+// execution count as failures, such as a template function returning an
+// error; renders that are canceled, run out of time, fail to write their
+// output or name an unknown template do not. When more than threshold renders
+// of a template fail within window, its circuit opens and renders fail with
+// ErrCircuitOpen for resetTime. After that, the circuit is half-open: a single
+// probe render is attempted, which closes the circuit if it succeeds and opens
+// it again if it fails.
+type CircuitBreaker struct {
+	threshold int
+	window    time.Duration
+	resetTime time.Duration
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state    CircuitState
+	failures []time.Time
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker creates a new CircuitBreaker. Its Middleware method must be
+// added to a renderer using Chain to take effect.
+func NewCircuitBreaker(threshold int, window, resetTime time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		window:    window,
+		resetTime: resetTime,
+		circuits:  make(map[string]*circuit),
+	}
+}
+
+// WithCircuitBreaker adds a new CircuitBreaker to the render pipeline. Use
+// NewCircuitBreaker with Chain instead to monitor its state.
+func WithCircuitBreaker(threshold int, window, resetTime time.Duration) Option {
+	return Chain(NewCircuitBreaker(threshold, window, resetTime).Middleware)
+}
+
+// State returns the current state of the circuit of the named template.
+func (cb *CircuitBreaker) State(name string) CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c, ok := cb.circuits[name]
+	if !ok {
+		return CircuitClosed
+	}
+	return cb.advance(c, time.Now())
+}
+
+// advance moves an open circuit to half-open once resetTime has passed and
+// returns its state. The caller must hold mu.
+func (cb *CircuitBreaker) advance(c *circuit, now time.Time) CircuitState {
+	if c.state == CircuitOpen && now.Sub(c.openedAt) >= cb.resetTime {
+		c.state = CircuitHalfOpen
+	}
+	return c.state
+}
+
+// Middleware is a RenderMiddleware that applies the circuit breaker.
+func (cb *CircuitBreaker) Middleware(next RenderHandler) RenderHandler {
+	return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+		if !cb.allow(name) {
+			return ErrCircuitOpen
+		}
+
+		err := next(ctx, w, name, data, funcs)
+		cb.record(name, err)
+		return err
+	}
+}
+
+func (cb *CircuitBreaker) allow(name string) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c, ok := cb.circuits[name]
+	if !ok {
+		return true
+	}
+
+	switch cb.advance(c, time.Now()) {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		if c.probing {
+			return false
+		}
+		c.probing = true
+	}
+	return true
+}
+
+// record updates the circuit of name with the result of a render. Circuits
+// are only created for templates failing to execute, so that the number of
+// circuits is bounded by the number of templates.
+func (cb *CircuitBreaker) record(name string, err error) {
+	failed := isExecFailure(err)
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c, ok := cb.circuits[name]
+	if !ok {
+		if !failed {
+			return
+		}
+		c = &circuit{}
+		cb.circuits[name] = c
+	}
+	now := time.Now()
+
+	if c.state == CircuitHalfOpen {
+		c.probing = false
+		switch {
+		case failed:
+			c.state = CircuitOpen
+			c.openedAt = now
+			c.failures = nil
+		case err == nil:
+			c.state = CircuitClosed
+			c.failures = nil
+		}
+		return
+	}
+
+	if !failed {
+		return
+	}
+
+	kept := c.failures[:0]
+	for _, t := range c.failures {
+		if now.Sub(t) < cb.window {
+			kept = append(kept, t)
+		}
+	}
+	c.failures = append(kept, now)
+
+	if len(c.failures) > cb.threshold {
+		c.state = CircuitOpen
+		c.openedAt = now
+		c.failures = nil
+	}
+}
+
+// isExecFailure reports whether err means that a template failed to execute,
+// as opposed to the render being canceled, the output failing to be written
+// or the template not existing.
+func isExecFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var execErr texttemplate.ExecError
+	var escapeErr *template.Error
+	return errors.As(err, &execErr) || errors.As(err, &escapeErr)
+}
@@ -0,0 +1,124 @@
+package tplx
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"io"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var errRenderFailed = errors.New("render failed")
+
+// newCircuitRenderer returns a renderer whose page template fails with the
+// error passed as data, if any.
+func newCircuitRenderer(tb testing.TB, cb *CircuitBreaker) Renderer {
+	tb.Helper()
+	fsys := fstest.MapFS{"page.html": {Data: []byte(`{{fail .}}ok`)}}
+	funcs := template.FuncMap{"fail": func(err error) (string, error) { return "", err }}
+	r, err := NewRenderer(fsys, Spec{"page": {{Name: "page", Path: "page.html"}}}, funcs, Chain(cb.Middleware))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return r
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	const resetTime = 10 * time.Millisecond
+	cb := NewCircuitBreaker(2, time.Minute, resetTime)
+	r := newCircuitRenderer(t, cb)
+	render := func(err error) error {
+		return r.Render(io.Discard, "page", err, nil)
+	}
+
+	for range 2 {
+		if err := render(errRenderFailed); !errors.Is(err, errRenderFailed) {
+			t.Fatalf("failing render: got %v", err)
+		}
+	}
+	if got := cb.State("page"); got != CircuitClosed {
+		t.Fatalf("at threshold: got %v, want closed", got)
+	}
+
+	_ = render(errRenderFailed)
+	if got := cb.State("page"); got != CircuitOpen {
+		t.Fatalf("above threshold: got %v, want open", got)
+	}
+	if err := render(nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("open circuit: got %v, want ErrCircuitOpen", err)
+	}
+
+	time.Sleep(resetTime)
+	if got := cb.State("page"); got != CircuitHalfOpen {
+		t.Fatalf("after reset time: got %v, want half-open", got)
+	}
+	if err := render(errRenderFailed); !errors.Is(err, errRenderFailed) {
+		t.Fatalf("failing probe: got %v", err)
+	}
+	if got := cb.State("page"); got != CircuitOpen {
+		t.Fatalf("after failing probe: got %v, want open", got)
+	}
+
+	time.Sleep(resetTime)
+	if err := render(nil); err != nil {
+		t.Fatalf("succeeding probe: %v", err)
+	}
+	if got := cb.State("page"); got != CircuitClosed {
+		t.Errorf("after succeeding probe: got %v, want closed", got)
+	}
+}
+
+func TestCircuitBreakerIgnoresNonExecutionErrors(t *testing.T) {
+	cb := NewCircuitBreaker(0, time.Minute, time.Minute)
+	r := newCircuitRenderer(t, cb)
+
+	if err := r.Render(io.Discard, "page", context.Canceled, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled render: got %v", err)
+	}
+	for _, name := range []string{"missing", "missing2"} {
+		if err := r.Render(io.Discard, name, nil, nil); !errors.Is(err, ErrUnknownTemplate) {
+			t.Fatalf("unknown template: got %v", err)
+		}
+	}
+	if err := r.Render(errorWriter{}, "page", nil, nil); err == nil {
+		t.Fatal("failing writer: got no error")
+	}
+
+	if got := cb.State("page"); got != CircuitClosed {
+		t.Errorf("got %v, want closed", got)
+	}
+	if got := len(cb.circuits); got != 0 {
+		t.Errorf("got %d circuits, want 0", got)
+	}
+}
+
+type errorWriter struct{}
+
+func (errorWriter) Write([]byte) (int, error) { return 0, errors.New("connection reset") }
+
+// TestCircuitBreakerConcurrent renders and inspects states from many
+// goroutines, for the race detector.
+func TestCircuitBreakerConcurrent(t *testing.T) {
+	cb := NewCircuitBreaker(5, time.Minute, time.Millisecond)
+	r := newCircuitRenderer(t, cb)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				var err error
+				if (i+j)%3 == 0 {
+					err = errRenderFailed
+				}
+				_ = r.Render(io.Discard, "page", err, nil)
+				_ = cb.State("page")
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,274 @@
+// Command tplxc checks the templates described by a tplx spec.
+//
+// Usage:
+//
+//	tplxc --spec <file> [--dir <directory>] [--funcs <names>] [--format] [--output-spec <file>]
+//
+// The spec file is YAML mapping top-level template names to lists of
+// fragments, using the same keys as tplx.MarshalSpec:
+//
+//	page:
+//	  - name: base
+//	    path: base.html
+//	  - name: page
+//	    path: page.html
+//	    stripComments: true
+//
+// Every template is parsed from the directory given by --dir, which defaults
+// to the current directory. If all of them parse, the issues reported by
+// tplx.LintSpec are printed. Since the spec cannot carry functions,
+// --funcs declares a comma-separated list of function names that templates
+// may call. With --format, each HTML template is additionally rendered without
+// data and its output validated; templates that cannot be rendered without
+// data are skipped. With --output-spec, a Go source file declaring the spec
+// as a tplx.Spec variable is written to the given file.
+//
+// tplxc exits with status 1 if any template fails to parse, has lint issues or
+// produces invalid output, and with status 2 on usage errors.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"html/template"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"forgejo.helveticanonstandard.net/helvetica/tplx"
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	specFile := flag.String("spec", "", "YAML spec `file`")
+	dir := flag.String("dir", ".", "template `directory`")
+	funcs := flag.String("funcs", "", "comma-separated `names` of functions the templates may call")
+	validate := flag.Bool("format", false, "render HTML templates without data and validate their output")
+	outputSpec := flag.String("output-spec", "", "write the spec as Go source to `file`")
+	pkg := flag.String("package", "templates", "package `name` of the file written by --output-spec")
+	variable := flag.String("var", "Spec", "variable `name` of the spec written by --output-spec")
+	flag.Parse()
+
+	if *specFile == "" || flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	spec, err := readSpec(*specFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tplxc: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !check(os.Stderr, spec, *dir, stubFuncs(*funcs), *validate) {
+		os.Exit(1)
+	}
+
+	if *outputSpec != "" {
+		src, err := generateSpec(spec, *pkg, *variable)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tplxc: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(*outputSpec, src, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "tplxc: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// readSpec reads a YAML spec. The document is converted to JSON and decoded
+// with tplx.UnmarshalSpec, so both formats share the same keys.
+func readSpec(name string) (tplx.Spec, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	b, err = json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	spec, err := tplx.UnmarshalSpec(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return spec, nil
+}
+
+// stubFuncs returns a function map defining each of the comma-separated names
+// as a function accepting any arguments, which is enough for parsing.
+func stubFuncs(names string) template.FuncMap {
+	funcs := make(template.FuncMap)
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			funcs[name] = func(...any) any { return nil }
+		}
+	}
+	return funcs
+}
+
+// check parses, lints and optionally validates the templates of spec, writing
+// each problem found to w.
+//
+// Returns whether no problems were found.
+func check(w io.Writer, spec tplx.Spec, dir string, funcs template.FuncMap, validate bool) bool {
+	fsys := os.DirFS(dir)
+	ok := true
+
+	var opts []tplx.Option
+	if validate {
+		opts = append(opts, tplx.WithDebug(true), tplx.WithHTMLValidation(true))
+	}
+
+	if hasTextFragments(spec) {
+		// The email renderer parses the plain-text fragments with
+		// text/template; the HTML fragments are validated on their own below.
+		if _, err := tplx.NewEmailRenderer(fsys, spec, funcs); err != nil {
+			printErrors(w, err)
+			return false
+		}
+		spec = htmlFragments(spec)
+	}
+
+	r, err := tplx.NewRenderer(fsys, spec, funcs, opts...)
+	if err != nil {
+		printErrors(w, err)
+		return false
+	}
+
+	// Parse errors are reported by LintSpec as well, so it only runs
+	// once all templates parsed.
+	for _, issue := range tplx.LintSpec(fsys, spec) {
+		fmt.Fprintln(w, issue)
+		ok = false
+	}
+
+	if !validate {
+		return ok
+	}
+
+	names := make([]string, 0, len(spec))
+	for name := range spec {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		err := r.Render(io.Discard, name, nil, nil)
+		var verr *tplx.ValidationError
+		switch {
+		case err == nil:
+		case errors.As(err, &verr):
+			fmt.Fprintln(w, verr)
+			ok = false
+		default:
+			fmt.Fprintf(w, "%s: skipped validation: %v\n", name, err)
+		}
+	}
+	return ok
+}
+
+func hasTextFragments(spec tplx.Spec) bool {
+	for _, metas := range spec {
+		for _, meta := range metas {
+			if meta.Format == tplx.FormatText {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// htmlFragments returns a copy of spec without plain-text fragments.
+func htmlFragments(spec tplx.Spec) tplx.Spec {
+	out := make(tplx.Spec, len(spec))
+	for name, metas := range spec {
+		out[name] = slices.DeleteFunc(slices.Clone(metas), func(meta tplx.Meta) bool {
+			return meta.Format == tplx.FormatText && meta.Extends == ""
+		})
+	}
+	return out
+}
+
+// printErrors writes err to w, one line per error if it is a tplx.MultiError.
+func printErrors(w io.Writer, err error) {
+	var merr tplx.MultiError
+	if !errors.As(err, &merr) {
+		fmt.Fprintln(w, err)
+		return
+	}
+	for _, err := range merr {
+		fmt.Fprintln(w, err)
+	}
+}
+
+// generateSpec returns gofmt'd Go source declaring spec as the variable
+// variable of package pkg.
+func generateSpec(spec tplx.Spec, pkg, variable string) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by tplxc. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	fmt.Fprintf(&buf, "import %q\n\n", "forgejo.helveticanonstandard.net/helvetica/tplx")
+	fmt.Fprintf(&buf, "var %s = tplx.Spec{\n", variable)
+
+	names := make([]string, 0, len(spec))
+	for name := range spec {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%q: {\n", name)
+		for _, meta := range spec[name] {
+			buf.WriteString("{")
+			if meta.Name != "" {
+				fmt.Fprintf(&buf, "Name: %q,", meta.Name)
+			}
+			if meta.Path != "" {
+				fmt.Fprintf(&buf, "Path: %q,", meta.Path)
+			}
+			if meta.StripComments {
+				buf.WriteString("StripComments: true,")
+			}
+			if meta.Extends != "" {
+				fmt.Fprintf(&buf, "Extends: %q,", meta.Extends)
+			}
+			if len(meta.Annotations) > 0 {
+				keys := make([]string, 0, len(meta.Annotations))
+				for k := range meta.Annotations {
+					keys = append(keys, k)
+				}
+				slices.Sort(keys)
+
+				buf.WriteString("Annotations: map[string]string{")
+				for _, k := range keys {
+					fmt.Fprintf(&buf, "%q: %q,", k, meta.Annotations[k])
+				}
+				buf.WriteString("},")
+			}
+			if meta.Format != "" {
+				fmt.Fprintf(&buf, "Format: %q,", meta.Format)
+			}
+			if meta.Encoding != "" {
+				fmt.Fprintf(&buf, "Encoding: %q,", meta.Encoding)
+			}
+			buf.WriteString("},\n")
+		}
+		buf.WriteString("},\n")
+	}
+	buf.WriteString("}\n")
+
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,73 @@
+package tplx
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// CollisionError describes a fragment defined by more than one Spec.
+//
+// TemplateName is the top-level template and FragmentName the fragment that
+// is defined multiple times. SpecIndices lists the positions of all specs
+// defining it, in ascending order.
+type CollisionError struct {
+	TemplateName string
+	FragmentName string
+	SpecIndices  []int
+}
+
+func (e CollisionError) Error() string {
+	return fmt.Sprintf("fragment %q of template %q is defined by specs %v", e.FragmentName, e.TemplateName, e.SpecIndices)
+}
+
+// CheckNamespaceCollisions reports fragments that would overwrite each other
+// if specs were merged.
+//
+// A collision occurs when the same top-level template in more than one of the
+// specs defines a fragment with the same name, since the template set of the
+// merged entry can hold only one of them.
+//
+// Returns the collisions sorted by template and fragment name.
+func CheckNamespaceCollisions(specs ...Spec) []CollisionError {
+	type key struct {
+		template string
+		fragment string
+	}
+
+	sources := make(map[key][]int)
+	for i, spec := range specs {
+		for name, metas := range spec {
+			for _, meta := range metas {
+				if meta.Extends != "" {
+					continue
+				}
+
+				k := key{template: name, fragment: meta.Name}
+				if indices := sources[k]; len(indices) == 0 || indices[len(indices)-1] != i {
+					sources[k] = append(indices, i)
+				}
+			}
+		}
+	}
+
+	var collisions []CollisionError
+	for k, indices := range sources {
+		if len(indices) > 1 {
+			collisions = append(collisions, CollisionError{
+				TemplateName: k.template,
+				FragmentName: k.fragment,
+				SpecIndices:  indices,
+			})
+		}
+	}
+
+	slices.SortFunc(collisions, func(a, b CollisionError) int {
+		return cmp.Or(
+			cmp.Compare(a.TemplateName, b.TemplateName),
+			cmp.Compare(a.FragmentName, b.FragmentName),
+		)
+	})
+
+	return collisions
+}
@@ -0,0 +1,40 @@
+package tplx
+
+// FragmentText is the in-memory text of a single template fragment.
+type FragmentText struct {
+	Name string
+	Text string
+}
+
+// CompileTemplate compiles a top-level template purely from in-memory text and
+// stores it under name, replacing any existing template of that name. This
+// allows template updates to be pushed to a running renderer, e.g. by a
+// template management service, without any file system access.
+//
+// The fragments are parsed in order with the renderer's global functions, and
+// one of them must be named name.
+//
+// Returns ErrInvalidSpec if no fragment is named name, or an error if a
+// fragment cannot be parsed, in which case the renderer is left unchanged.
+func (r *renderer) CompileTemplate(name string, fragments []FragmentText) error {
+	inc := false
+	for _, f := range fragments {
+		if f.Name == name {
+			inc = true
+		}
+	}
+
+	if !inc {
+		return ErrInvalidSpec
+	}
+
+	e, err := r.c.compile(name, fragments, r.funcs)
+	if err != nil {
+		return err
+	}
+
+	return r.update(func(m map[string]*entry) error {
+		m[name] = e
+		return nil
+	})
+}
@@ -0,0 +1,63 @@
+package tplx
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var errorType = reflect.TypeFor[error]()
+
+// ComposeFuncs composes single-argument functions into one function that
+// applies them from left to right, for building reusable pipeline functions
+// in template function libraries:
+//
+//	funcs := template.FuncMap{
+//		"summary": tplx.ComposeFuncs(strings.TrimSpace, strings.ToUpper),
+//	}
+//
+// Each function must take exactly one argument and return either a single
+// value or a value and an error. The result of each function must be
+// assignable to the argument of the next. The composed function takes the
+// argument of the first function and returns the result of the last one
+// together with an error, which is the first error returned by any of the
+// functions; functions after a failing one are not called.
+//
+// ComposeFuncs panics if no functions are given or they cannot be composed,
+// like template.FuncMap registration does for invalid functions.
+func ComposeFuncs(fns ...any) any {
+	if len(fns) == 0 {
+		panic("tplx: ComposeFuncs called without functions")
+	}
+
+	vals := make([]reflect.Value, len(fns))
+	for i, fn := range fns {
+		v := reflect.ValueOf(fn)
+		t := v.Type()
+		if t.Kind() != reflect.Func || t.NumIn() != 1 || t.IsVariadic() {
+			panic(fmt.Sprintf("tplx: ComposeFuncs argument %d is not a single-argument function", i))
+		}
+		if t.NumOut() == 0 || t.NumOut() > 2 || t.NumOut() == 2 && t.Out(1) != errorType {
+			panic(fmt.Sprintf("tplx: ComposeFuncs argument %d must return a value and an optional error", i))
+		}
+		if i > 0 && !vals[i-1].Type().Out(0).AssignableTo(t.In(0)) {
+			panic(fmt.Sprintf("tplx: ComposeFuncs argument %d cannot take the result of argument %d", i, i-1))
+		}
+		vals[i] = v
+	}
+
+	in := vals[0].Type().In(0)
+	out := vals[len(vals)-1].Type().Out(0)
+	ft := reflect.FuncOf([]reflect.Type{in}, []reflect.Type{out, errorType}, false)
+
+	return reflect.MakeFunc(ft, func(args []reflect.Value) []reflect.Value {
+		arg := args[0]
+		for _, v := range vals {
+			res := v.Call([]reflect.Value{arg})
+			if len(res) == 2 && !res[1].IsNil() {
+				return []reflect.Value{reflect.Zero(out), res[1]}
+			}
+			arg = res[0]
+		}
+		return []reflect.Value{arg, reflect.Zero(errorType)}
+	}).Interface()
+}
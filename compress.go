@@ -0,0 +1,141 @@
+package tplx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrUnsupportedEncoding is returned by CompressWriter for content encodings
+// it cannot produce.
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// DeflateWriter returns a writer that compresses what is written to it with
+// DEFLATE before passing it to w, as an alternative to gzip for clients and
+// CDNs that prefer it. As the deflate content encoding of HTTP requires, the
+// compressed stream is wrapped in the zlib format (RFC 1950). Output is buffered until threshold bytes have been
+// written, and responses that remain smaller are sent uncompressed, since
+// compressing them would not pay off. Once compression starts, the
+// Content-Encoding header is set to deflate, Vary includes Accept-Encoding and
+// any Content-Length header is removed.
+//
+// The returned writer implements io.Closer and http.Flusher. It must be closed
+// after the last write, which flushes the compressed stream or the buffered
+// output; flushing starts compression even below the threshold.
+func DeflateWriter(w http.ResponseWriter, threshold int) io.Writer {
+	return &compressWriter{
+		w:         w,
+		encoding:  "deflate",
+		threshold: threshold,
+		newWriter: func(w io.Writer) compressor {
+			return zlib.NewWriter(w)
+		},
+	}
+}
+
+// CompressWriter returns a writer compressing with the given content
+// encoding, which is "gzip" or "deflate", and otherwise behaves like
+// DeflateWriter. Brotli ("br") would require a third-party implementation and
+// is not supported.
+//
+// Returns the writer, or ErrUnsupportedEncoding if encoding is not supported.
+func CompressWriter(w http.ResponseWriter, encoding string, threshold int) (io.Writer, error) {
+	switch encoding {
+	case "gzip":
+		return &compressWriter{
+			w:         w,
+			encoding:  "gzip",
+			threshold: threshold,
+			newWriter: func(w io.Writer) compressor {
+				return gzip.NewWriter(w)
+			},
+		}, nil
+	case "deflate":
+		return DeflateWriter(w, threshold), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, encoding)
+	}
+}
+
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// compressWriter buffers output up to a threshold before deciding whether to
+// compress it.
+type compressWriter struct {
+	w         http.ResponseWriter
+	encoding  string
+	threshold int
+	newWriter func(w io.Writer) compressor
+
+	buf bytes.Buffer
+	cw  compressor
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.cw != nil {
+		return cw.cw.Write(p)
+	}
+
+	cw.buf.Write(p)
+	if cw.buf.Len() >= cw.threshold {
+		if err := cw.start(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// start sets the response headers and compresses the buffered output. The
+// content type is detected from the uncompressed output, as net/http would
+// otherwise sniff the compressed bytes.
+func (cw *compressWriter) start() error {
+	h := cw.w.Header()
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", http.DetectContentType(cw.buf.Bytes()))
+	}
+	h.Set("Content-Encoding", cw.encoding)
+	h.Add("Vary", "Accept-Encoding")
+	h.Del("Content-Length")
+
+	cw.cw = cw.newWriter(cw.w)
+	_, err := cw.cw.Write(cw.buf.Bytes())
+	cw.buf.Reset()
+	return err
+}
+
+// Flush sends everything written so far to the client.
+func (cw *compressWriter) Flush() {
+	if cw.cw == nil {
+		if cw.buf.Len() == 0 {
+			return
+		}
+		if err := cw.start(); err != nil {
+			return
+		}
+	}
+
+	if err := cw.cw.Flush(); err != nil {
+		return
+	}
+	if f, ok := cw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close finishes the compressed stream, or writes the buffered output
+// uncompressed if the threshold was never reached.
+func (cw *compressWriter) Close() error {
+	if cw.cw == nil {
+		_, err := cw.w.Write(cw.buf.Bytes())
+		cw.buf.Reset()
+		return err
+	}
+	return cw.cw.Close()
+}
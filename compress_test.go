@@ -0,0 +1,73 @@
+package tplx
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompressWriterRoundTrip(t *testing.T) {
+	body := strings.Repeat("<p>compressible</p>", 100)
+
+	tests := []struct {
+		name      string
+		encoding  string
+		threshold int
+		want      string // Content-Encoding
+	}{
+		{name: "gzip", encoding: "gzip", threshold: 16, want: "gzip"},
+		{name: "deflate", encoding: "deflate", threshold: 16, want: "deflate"},
+		{name: "below threshold", encoding: "deflate", threshold: len(body) + 1, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			w, err := CompressWriter(rec, tt.encoding, tt.threshold)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.WriteString(w, body); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.(io.Closer).Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Decode the body as a client would, according to the header.
+			var r io.Reader = rec.Body
+			switch enc := rec.Header().Get("Content-Encoding"); enc {
+			case "gzip":
+				r, err = gzip.NewReader(r)
+			case "deflate":
+				r, err = zlib.NewReader(r)
+			case "":
+			default:
+				t.Fatalf("unexpected Content-Encoding %q", enc)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != tt.want {
+				t.Errorf("Content-Encoding: got %q, want %q", got, tt.want)
+			}
+
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != body {
+				t.Errorf("decoded body differs: got %d bytes, want %d", len(got), len(body))
+			}
+		})
+	}
+}
+
+func TestCompressWriterUnsupported(t *testing.T) {
+	if _, err := CompressWriter(httptest.NewRecorder(), "br", 0); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("br: got %v, want ErrUnsupportedEncoding", err)
+	}
+}
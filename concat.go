@@ -0,0 +1,53 @@
+package tplx
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"io"
+	"slices"
+)
+
+// concatRenderer renders several templates of a renderer in sequence.
+type concatRenderer struct {
+	r     Renderer
+	names []string
+}
+
+// ConcatRenderer returns a Renderer for a virtual template whose output is the
+// concatenation of rendering each of the named templates of r in order, with
+// the same data and funcs. Unlike a layout, no template wraps the others.
+//
+// The returned Renderer serves only this single virtual template, so the name
+// passed to its Render method is ignored.
+//
+// Returns an error if names is empty, or ErrUnknownTemplate if r reports
+// through a Has(name string) bool method that one of the names does not exist.
+func ConcatRenderer(r Renderer, names []string) (Renderer, error) {
+	if len(names) == 0 {
+		return nil, errors.New("no templates to concatenate")
+	}
+
+	if has, ok := r.(interface{ Has(string) bool }); ok {
+		for _, name := range names {
+			if !has.Has(name) {
+				return nil, ErrUnknownTemplate
+			}
+		}
+	}
+
+	return concatRenderer{r: r, names: slices.Clone(names)}, nil
+}
+
+func (c concatRenderer) Render(w io.Writer, _ string, data any, funcs template.FuncMap) error {
+	return c.RenderContext(context.Background(), w, "", data, funcs)
+}
+
+func (c concatRenderer) RenderContext(ctx context.Context, w io.Writer, _ string, data any, funcs template.FuncMap) error {
+	for _, name := range c.names {
+		if err := RenderContext(ctx, c.r, w, name, data, funcs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package tplx
+
+import (
+	"html/template"
+	"io"
+)
+
+// PreCalculateContentLength renders a named template using r and returns the
+// number of bytes the output consists of, without holding the output in
+// memory. The result can be sent as the Content-Length header before the
+// response body is rendered, e.g. so that browsers can show the progress of a
+// large download.
+//
+// The length is a hint, not a guarantee: templates that depend on the time,
+// random values or mutable state may produce output of a different length when
+// rendered again. Since the template is rendered twice, this is only
+// worthwhile for responses large enough to benefit from a known length.
+//
+// Returns the length of the output or an error if the template cannot be
+// rendered.
+func PreCalculateContentLength(r Renderer, name string, data any, funcs template.FuncMap) (int64, error) {
+	cw := &countingWriter{w: io.Discard}
+	if err := r.Render(cw, name, data, funcs); err != nil {
+		return 0, err
+	}
+	return int64(cw.n), nil
+}
@@ -0,0 +1,70 @@
+package tplx
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+type rendererKey struct{}
+
+// NewContext returns a copy of ctx carrying the renderer r.
+func NewContext(ctx context.Context, r Renderer) context.Context {
+	return context.WithValue(ctx, rendererKey{}, r)
+}
+
+// FromContext returns the renderer stored in ctx by NewContext.
+func FromContext(ctx context.Context) (Renderer, bool) {
+	r, ok := ctx.Value(rendererKey{}).(Renderer)
+	return r, ok
+}
+
+type requestKey struct{}
+
+// NewRequestContext returns a copy of ctx carrying the HTTP request req, which
+// allows options to access request-bound values such as session data at
+// render time.
+func NewRequestContext(ctx context.Context, req *http.Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, req)
+}
+
+// RequestFromContext returns the HTTP request stored in ctx by
+// NewRequestContext.
+func RequestFromContext(ctx context.Context) (*http.Request, bool) {
+	req, ok := ctx.Value(requestKey{}).(*http.Request)
+	return req, ok
+}
+
+type responseKey struct{}
+
+// NewResponseContext returns a copy of ctx carrying the HTTP response writer
+// w, which allows options to set response headers at render time even when
+// the writer passed to the render is a buffer or wraps w.
+func NewResponseContext(ctx context.Context, w http.ResponseWriter) context.Context {
+	return context.WithValue(ctx, responseKey{}, w)
+}
+
+// ResponseFromContext returns the HTTP response writer stored in ctx by
+// NewResponseContext.
+func ResponseFromContext(ctx context.Context) (http.ResponseWriter, bool) {
+	w, ok := ctx.Value(responseKey{}).(http.ResponseWriter)
+	return w, ok
+}
+
+// responseWriter returns w if it is an http.ResponseWriter and the response
+// writer stored in ctx otherwise.
+func responseWriter(ctx context.Context, w io.Writer) (http.ResponseWriter, bool) {
+	if rw, ok := w.(http.ResponseWriter); ok {
+		return rw, true
+	}
+	return ResponseFromContext(ctx)
+}
+
+// RequestMiddleware stores each request and its response writer in its own
+// context using NewRequestContext and NewResponseContext.
+func RequestMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := NewResponseContext(NewRequestContext(req.Context(), req), w)
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
@@ -0,0 +1,93 @@
+package tplx
+
+import (
+	"context"
+	"html/template"
+	"reflect"
+)
+
+var contextType = reflect.TypeFor[context.Context]()
+
+// WithContextInjection passes the render context to template functions that
+// accept a context.Context as their first parameter, so that functions calling
+// databases or other services can propagate tracing information and
+// cancellation.
+//
+// Before each render, every such function, whether global, registered for a
+// fragment or passed to Render, is replaced by a closure over the render
+// context that takes the remaining parameters, so templates call the function
+// without the context argument. Renders that do not carry a context, such as
+// RenderBlock, inject context.Background.
+func WithContextInjection() Option {
+	return func(c *config) {
+		c.contextInjection = true
+	}
+}
+
+// contextFuncs returns the functions of funcs whose first parameter is a
+// context.Context.
+func contextFuncs(funcs template.FuncMap) template.FuncMap {
+	var m template.FuncMap
+	for name, fn := range funcs {
+		if !acceptsContext(fn) {
+			continue
+		}
+		if m == nil {
+			m = make(template.FuncMap)
+		}
+		m[name] = fn
+	}
+	return m
+}
+
+func acceptsContext(fn any) bool {
+	t := reflect.TypeOf(fn)
+	return t != nil && t.Kind() == reflect.Func && t.NumIn() > 0 && t.In(0) == contextType
+}
+
+// injectContext returns the functions to render with when the context ctx is
+// injected into the parsed functions ctxFuncs and the per-render functions
+// funcs. Per-render functions take precedence. If no function accepts a
+// context, funcs is returned unchanged.
+func injectContext(ctx context.Context, ctxFuncs, funcs template.FuncMap) template.FuncMap {
+	if len(ctxFuncs) == 0 && len(contextFuncs(funcs)) == 0 {
+		return funcs
+	}
+
+	m := make(template.FuncMap, len(ctxFuncs)+len(funcs))
+	for name, fn := range ctxFuncs {
+		m[name] = bindContext(ctx, fn)
+	}
+	for name, fn := range funcs {
+		if acceptsContext(fn) {
+			fn = bindContext(ctx, fn)
+		}
+		m[name] = fn
+	}
+	return m
+}
+
+// bindContext returns a function like fn without its first parameter, which
+// is always passed ctx.
+func bindContext(ctx context.Context, fn any) any {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+
+	in := make([]reflect.Type, t.NumIn()-1)
+	for i := range in {
+		in[i] = t.In(i + 1)
+	}
+	out := make([]reflect.Type, t.NumOut())
+	for i := range out {
+		out[i] = t.Out(i)
+	}
+
+	ctxv := reflect.ValueOf(&ctx).Elem()
+	return reflect.MakeFunc(reflect.FuncOf(in, out, t.IsVariadic()), func(args []reflect.Value) []reflect.Value {
+		args = append([]reflect.Value{ctxv}, args...)
+		if t.IsVariadic() {
+			return v.CallSlice(args)
+		}
+		return v.Call(args)
+	}).Interface()
+}
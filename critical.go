@@ -0,0 +1,61 @@
+package tplx
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"time"
+)
+
+// WithCriticalTemplate marks the named template as business-critical.
+//
+// Renders of a critical template are detached from the cancellation of the
+// parent context and run with a deadline of extension past the parent's
+// deadline, or extension from now if the parent has no deadline. This lets an
+// order receipt finish rendering even if the request context has just
+// expired. The extension is bounded by WithCriticalTemplateLimit.
+//
+// The critical deadline replaces the one set by WithRenderTimeout, and as with
+// that option, execution is aborted at the first write after it has passed.
+func WithCriticalTemplate(name string, extension time.Duration) Option {
+	return func(c *config) {
+		if c.critical == nil {
+			c.critical = make(map[string]time.Duration)
+		}
+		c.critical[name] = extension
+	}
+}
+
+// WithCriticalTemplateLimit sets an absolute upper bound on how long a render
+// of a critical template may take, measured from the start of the render,
+// regardless of the parent deadline. A limit of zero means no bound.
+func WithCriticalTemplateLimit(limit time.Duration) Option {
+	return func(c *config) {
+		c.criticalLimit = limit
+	}
+}
+
+// criticalRender runs critical templates through detached with their
+// extended deadline and all other templates through next.
+func (c *config) criticalRender(next, detached RenderHandler) RenderHandler {
+	return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+		extension, ok := c.critical[name]
+		if !ok {
+			return next(ctx, w, name, data, funcs)
+		}
+
+		now := time.Now()
+		deadline := now.Add(extension)
+		if d, ok := ctx.Deadline(); ok {
+			deadline = d.Add(extension)
+		}
+		if c.criticalLimit > 0 && deadline.After(now.Add(c.criticalLimit)) {
+			deadline = now.Add(c.criticalLimit)
+		}
+
+		ctx, cancel := context.WithDeadline(context.WithoutCancel(ctx), deadline)
+		defer cancel()
+
+		return detached(ctx, ctxWriter{ctx: ctx, w: w}, name, data, funcs)
+	}
+}
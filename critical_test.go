@@ -0,0 +1,81 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCriticalTemplateWithTimeout(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.html":    {Data: []byte("<p>{{.}}</p>")},
+		"receipt.html": {Data: []byte("<p>receipt {{.}}</p>")},
+	}
+	spec := Spec{
+		"page":    {{Name: "page", Path: "page.html"}},
+		"receipt": {{Name: "receipt", Path: "receipt.html"}},
+	}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		tmpl    string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "regular template",
+			opts:    []Option{WithRenderTimeout(time.Minute), WithCriticalTemplate("receipt", time.Minute)},
+			tmpl:    "page",
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name: "critical template",
+			opts: []Option{WithRenderTimeout(time.Minute), WithCriticalTemplate("receipt", time.Minute)},
+			tmpl: "receipt",
+			want: "<p>receipt 42</p>",
+		},
+		{
+			name:    "critical template past limit",
+			opts:    []Option{WithRenderTimeout(time.Minute), WithCriticalTemplate("receipt", time.Minute), WithCriticalTemplateLimit(time.Nanosecond)},
+			tmpl:    "receipt",
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:    "critical template past limit without timeout",
+			opts:    []Option{WithCriticalTemplate("receipt", time.Minute), WithCriticalTemplateLimit(time.Nanosecond)},
+			tmpl:    "receipt",
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewRenderer(fsys, spec, nil, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The request deadline has just passed.
+			ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Millisecond))
+			defer cancel()
+
+			var buf bytes.Buffer
+			err = RenderContext(ctx, r, &buf, tt.tmpl, 42, nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
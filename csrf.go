@@ -0,0 +1,16 @@
+package tplx
+
+import "net/http"
+
+// WithCSRFTokenKey injects a CSRF token into the template data under key.
+//
+// The extractor parameter obtains the token from the request, for example from
+// the CSRF protection middleware in use. The token is only injected when the
+// render context carries a request (see NewRequestContext), i.e. when the
+// render was triggered by an HTTP handler. Like other injected values, it can
+// only be added to nil data or data of type map[string]any.
+func WithCSRFTokenKey(key string, extractor func(*http.Request) string) Option {
+	return injectFromRequest(key, func(r *http.Request) any {
+		return extractor(r)
+	})
+}
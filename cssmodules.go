@@ -0,0 +1,70 @@
+package tplx
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"maps"
+	"strings"
+)
+
+// WithCSSModules registers the template function cssClass, which maps a
+// source class name to its hashed name according to manifest, as generated
+// for CSS modules by build systems. Names missing from the manifest are
+// returned unchanged, so templates keep working in development without a
+// build. Several space-separated class names can be mapped in a single call:
+//
+//	<button class="{{ cssClass "button primary" }}">
+func WithCSSModules(manifest map[string]string) Option {
+	manifest = maps.Clone(manifest)
+
+	return func(c *config) {
+		c.addFuncs(template.FuncMap{
+			"cssClass": func(name string) string {
+				classes := strings.Fields(name)
+				for i, class := range classes {
+					if hashed, ok := manifest[class]; ok {
+						classes[i] = hashed
+					}
+				}
+				return strings.Join(classes, " ")
+			},
+		})
+	}
+}
+
+// LoadCSSModulesManifest reads a CSS modules JSON manifest from the file at
+// path in fsys. The manifest is either an object mapping class names to
+// hashed names, or an object mapping CSS files to such objects, as written by
+// postcss-modules for a whole project, in which case the mappings of all files
+// are merged.
+//
+// Returns an error if the file cannot be read or is not a manifest.
+func LoadCSSModulesManifest(fsys fs.FS, path string) (map[string]string, error) {
+	b, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read CSS modules manifest: %w", err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("invalid CSS modules manifest: %w", err)
+	}
+
+	manifest := make(map[string]string, len(raw))
+	for key, v := range raw {
+		var hashed string
+		if err := json.Unmarshal(v, &hashed); err == nil {
+			manifest[key] = hashed
+			continue
+		}
+
+		var classes map[string]string
+		if err := json.Unmarshal(v, &classes); err != nil {
+			return nil, fmt.Errorf("invalid CSS modules manifest entry %q: %w", key, err)
+		}
+		maps.Copy(manifest, classes)
+	}
+	return manifest, nil
+}
@@ -0,0 +1,55 @@
+package tplx
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"maps"
+	"net/http"
+)
+
+// injectData returns data with key set to value.
+//
+// Only nil data and data of type map[string]any can carry injected values. A
+// map is copied rather than modified in place. Any other data is returned
+// unchanged.
+func injectData(data any, key string, value any) any {
+	switch d := data.(type) {
+	case nil:
+		return map[string]any{key: value}
+	case map[string]any:
+		d = maps.Clone(d)
+		d[key] = value
+		return d
+	default:
+		return data
+	}
+}
+
+// canInject reports whether injectData can add values to data.
+func canInject(data any) bool {
+	switch data.(type) {
+	case nil, map[string]any:
+		return true
+	default:
+		return false
+	}
+}
+
+// injectFromRequest returns an option that injects the value returned by fn
+// for the request in the render context into the data under key. Renders
+// without a request or with data that cannot carry the value are left
+// untouched, and fn is not called for them, so that values such as flash
+// messages are not consumed without being shown.
+func injectFromRequest(key string, fn func(r *http.Request) any) Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, func(next RenderHandler) RenderHandler {
+			return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+				if req, ok := RequestFromContext(ctx); ok && canInject(data) {
+					data = injectData(data, key, fn(req))
+				}
+				return next(ctx, w, name, data, funcs)
+			}
+		})
+	}
+}
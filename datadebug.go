@@ -0,0 +1,71 @@
+package tplx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+)
+
+// DataDebuggerOption configures WithDataDebugger.
+type DataDebuggerOption func(*dataDebugger)
+
+type dataDebugger struct {
+	maxSize int
+}
+
+// MaxDataLogSize truncates the logged data to n bytes, to avoid flooding the
+// log with large data. A size of 0 or less logs the data in full, which is the
+// default.
+func MaxDataLogSize(n int) DataDebuggerOption {
+	return func(d *dataDebugger) {
+		d.maxSize = n
+	}
+}
+
+// WithDataDebugger logs the data of every render as JSON to logger at debug
+// level, to inspect what a template received when it renders incorrectly.
+// Data that cannot be encoded as JSON, e.g. because it contains functions or
+// channels, is logged in the %+v format of the fmt package instead. If logger
+// is nil, the logger of the renderer is used (see WithLogger).
+//
+// The data is only encoded if the logger has debug level enabled.
+func WithDataDebugger(logger *slog.Logger, opts ...DataDebuggerOption) Option {
+	var d dataDebugger
+	for _, opt := range opts {
+		opt(&d)
+	}
+
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, func(next RenderHandler) RenderHandler {
+			return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+				logger := logger
+				if logger == nil {
+					logger = c.log()
+				}
+
+				if logger.Enabled(ctx, slog.LevelDebug) {
+					logger.DebugContext(ctx, "rendering template", "template", name, "data", d.format(data))
+				}
+				return next(ctx, w, name, data, funcs)
+			}
+		})
+	}
+}
+
+// format returns data encoded for the log.
+func (d dataDebugger) format(data any) string {
+	var s string
+	if b, err := json.Marshal(data); err == nil {
+		s = string(b)
+	} else {
+		s = fmt.Sprintf("%+v", data)
+	}
+
+	if d.maxSize > 0 && len(s) > d.maxSize {
+		s = s[:d.maxSize] + "…"
+	}
+	return s
+}
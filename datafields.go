@@ -0,0 +1,218 @@
+package tplx
+
+import (
+	"slices"
+	"strings"
+	"text/template/parse"
+)
+
+// FieldInfo describes a reference to the data passed to a template, as found
+// by ExtractDataFields.
+//
+// Name is the path of the field from the root of the data, with the elements
+// separated by dots and "[]" marking the elements of a value ranged over, as
+// in "Items[].Title". IsMethod reports whether the field is called with
+// arguments, which makes it a method, and ArgCount is the largest number of
+// arguments it is called with.
+type FieldInfo struct {
+	Name     string
+	IsMethod bool
+	ArgCount int
+}
+
+// ExtractDataFields lists the fields of the data that the top-level template
+// name refers to, e.g. for generating documentation of the data a template
+// expects.
+//
+// The parse tree is walked from the top-level template into the
+// sub-templates it invokes, following how {{with}}, {{range}} and {{template}}
+// change the meaning of dot. References through variables other than $ are
+// excluded, as are references whose path cannot be determined, such as fields
+// of values ranged over through a variable or returned by a function. Since
+// the parse tree does not tell fields and methods apart, a reference is only
+// reported as a method if it is called with arguments.
+//
+// Returns the fields sorted by name, or ErrUnknownTemplate if the template
+// does not exist.
+func (r *renderer) ExtractDataFields(name string) ([]FieldInfo, error) {
+	e, ok := r.lookup(name)
+	if !ok {
+		return nil, ErrUnknownTemplate
+	}
+
+	x := &fieldExtractor{
+		lookup: func(name string) *parse.Tree {
+			if t := e.base.Lookup(name); t != nil {
+				return t.Tree
+			}
+			return nil
+		},
+		fields:  make(map[string]*FieldInfo),
+		visited: make(map[[2]string]bool),
+	}
+	x.walkTemplate(name, dotPath{valid: true})
+
+	fields := make([]FieldInfo, 0, len(x.fields))
+	for _, f := range x.fields {
+		fields = append(fields, *f)
+	}
+	slices.SortFunc(fields, func(a, b FieldInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return fields, nil
+}
+
+// dotPath is the path of dot from the root of the data. It is invalid if the
+// path cannot be determined.
+type dotPath struct {
+	path  string
+	valid bool
+}
+
+func (d dotPath) join(idents ...string) dotPath {
+	if !d.valid {
+		return d
+	}
+	path := d.path
+	for _, ident := range idents {
+		if path != "" {
+			path += "."
+		}
+		path += ident
+	}
+	return dotPath{path: path, valid: true}
+}
+
+type fieldExtractor struct {
+	lookup  func(name string) *parse.Tree
+	fields  map[string]*FieldInfo
+	visited map[[2]string]bool
+}
+
+// record adds the fields along the path of idents below dot. The last of
+// them is called with args arguments.
+func (x *fieldExtractor) record(dot dotPath, idents []string, args int) {
+	if !dot.valid {
+		return
+	}
+	for i := range idents {
+		p := dot.join(idents[:i+1]...).path
+		f, ok := x.fields[p]
+		if !ok {
+			f = &FieldInfo{Name: p}
+			x.fields[p] = f
+		}
+		if i == len(idents)-1 && args > 0 {
+			f.IsMethod = true
+			f.ArgCount = max(f.ArgCount, args)
+		}
+	}
+}
+
+func (x *fieldExtractor) walkTemplate(name string, dot dotPath) {
+	key := [2]string{name, dot.path}
+	if !dot.valid || x.visited[key] {
+		return
+	}
+	x.visited[key] = true
+
+	if tree := x.lookup(name); tree != nil && tree.Root != nil {
+		x.walk(tree.Root, dot, dot)
+	}
+}
+
+// walk collects the fields referenced below n, where dot is the path of dot
+// and root the path of $.
+func (x *fieldExtractor) walk(n parse.Node, dot, root dotPath) {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			x.walk(c, dot, root)
+		}
+	case *parse.ActionNode:
+		x.walkPipe(n.Pipe, dot, root)
+	case *parse.IfNode:
+		x.walkPipe(n.Pipe, dot, root)
+		x.walk(n.List, dot, root)
+		x.walk(n.ElseList, dot, root)
+	case *parse.WithNode:
+		x.walkPipe(n.Pipe, dot, root)
+		x.walk(n.List, x.pipeDot(n.Pipe, dot, root), root)
+		x.walk(n.ElseList, dot, root)
+	case *parse.RangeNode:
+		x.walkPipe(n.Pipe, dot, root)
+		elem := x.pipeDot(n.Pipe, dot, root)
+		if elem.valid {
+			elem.path += "[]"
+		}
+		x.walk(n.List, elem, root)
+		x.walk(n.ElseList, dot, root)
+	case *parse.TemplateNode:
+		if n.Pipe == nil {
+			return
+		}
+		x.walkPipe(n.Pipe, dot, root)
+		x.walkTemplate(n.Name, x.pipeDot(n.Pipe, dot, root))
+	}
+}
+
+func (x *fieldExtractor) walkPipe(p *parse.PipeNode, dot, root dotPath) {
+	if p == nil {
+		return
+	}
+	for i, cmd := range p.Cmds {
+		// Commands after the first receive the result of the previous one
+		// as their last argument.
+		piped := 0
+		if i > 0 {
+			piped = 1
+		}
+		for j, arg := range cmd.Args {
+			args := 0
+			if j == 0 {
+				args = len(cmd.Args) - 1 + piped
+			}
+			x.walkArg(arg, args, dot, root)
+		}
+	}
+}
+
+// walkArg collects the fields referenced by an argument of a command, which
+// is called with args arguments if it is the first one.
+func (x *fieldExtractor) walkArg(n parse.Node, args int, dot, root dotPath) {
+	switch n := n.(type) {
+	case *parse.FieldNode:
+		x.record(dot, n.Ident, args)
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			x.record(root, n.Ident[1:], args)
+		}
+	case *parse.ChainNode:
+		x.walkArg(n.Node, 0, dot, root)
+	case *parse.PipeNode:
+		x.walkPipe(n, dot, root)
+	}
+}
+
+// pipeDot returns the path of the value of p, which becomes dot in the body
+// of {{with}}, {{range}} and {{template}}.
+func (x *fieldExtractor) pipeDot(p *parse.PipeNode, dot, root dotPath) dotPath {
+	if p == nil || len(p.Cmds) != 1 || len(p.Cmds[0].Args) != 1 {
+		return dotPath{}
+	}
+
+	switch n := p.Cmds[0].Args[0].(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		return dot.join(n.Ident...)
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" {
+			return root.join(n.Ident[1:]...)
+		}
+	}
+	return dotPath{}
+}
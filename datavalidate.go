@@ -0,0 +1,38 @@
+package tplx
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+)
+
+// ValidatorFunc checks the data passed to a render, returning an error if it
+// is invalid.
+type ValidatorFunc func(data any) error
+
+// WithValidation calls validate with the data of each render before the
+// template is executed, so that incomplete data is rejected with a
+// descriptive error instead of failing halfway through the template, e.g. on a
+// nil pointer. If validate fails, nothing is written and the render returns
+// its error, wrapped so that errors.As still finds it.
+//
+// Struct tag validators fit directly, e.g. with
+// github.com/go-playground/validator/v10:
+//
+//	tplx.WithValidation(validator.New().Struct)
+//
+// Note that such validators require the data to be a struct or a pointer to
+// one, and fail for other data.
+func WithValidation(validate ValidatorFunc) Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, func(next RenderHandler) RenderHandler {
+			return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+				if err := validate(data); err != nil {
+					return fmt.Errorf("invalid data for template %q: %w", name, err)
+				}
+				return next(ctx, w, name, data, funcs)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package tplx
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestWithValidation(t *testing.T) {
+	type page struct {
+		Title string `validate:"required"`
+	}
+
+	fsys := fstest.MapFS{"page.html": {Data: []byte("<h1>{{.Title}}</h1>")}}
+	spec := Spec{"page": {{Name: "page", Path: "page.html"}}}
+	r, err := NewRenderer(fsys, spec, nil, WithValidation(validator.New().Struct))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := r.Render(&buf, "page", page{Title: "Home"}, nil); err != nil {
+		t.Fatalf("valid data: %v", err)
+	}
+	if got, want := buf.String(), "<h1>Home</h1>"; got != want {
+		t.Errorf("valid data: got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	err = r.Render(&buf, "page", page{}, nil)
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("invalid data: got error %v, want validator.ValidationErrors", err)
+	}
+	if len(verrs) != 1 || verrs[0].Field() != "Title" || verrs[0].Tag() != "required" {
+		t.Errorf("invalid data: got %v, want required Title", verrs)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("invalid data: wrote %q, want nothing", buf.String())
+	}
+}
@@ -0,0 +1,30 @@
+package tplx
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// DeadlineWriter returns a writer that writes to w until deadline has passed.
+// It is meant to be passed to Render when rendering directly to a connection
+// with a write deadline, so that slow template execution fails with an error
+// instead of the output being silently truncated.
+//
+// Every Write after the deadline fails with os.ErrDeadlineExceeded, the error
+// net.Conn uses for exceeded deadlines, without writing anything to w.
+func DeadlineWriter(w io.Writer, deadline time.Time) io.Writer {
+	return deadlineWriter{w: w, deadline: deadline}
+}
+
+type deadlineWriter struct {
+	w        io.Writer
+	deadline time.Time
+}
+
+func (dw deadlineWriter) Write(p []byte) (int, error) {
+	if !time.Now().Before(dw.deadline) {
+		return 0, os.ErrDeadlineExceeded
+	}
+	return dw.w.Write(p)
+}
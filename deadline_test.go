@@ -0,0 +1,58 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func newDeadlineRenderer(t *testing.T, opts ...Option) Renderer {
+	t.Helper()
+	fsys := fstest.MapFS{"page.html": {Data: []byte("<p>{{.}}</p>")}}
+	r, err := NewRenderer(fsys, Spec{"page": {{Name: "page", Path: "page.html"}}}, nil, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestDeadlineWriter(t *testing.T) {
+	r := newDeadlineRenderer(t)
+
+	var buf bytes.Buffer
+	if err := r.Render(DeadlineWriter(&buf, time.Now().Add(time.Hour)), "page", "ok", nil); err != nil {
+		t.Fatalf("future deadline: %v", err)
+	}
+	if got, want := buf.String(), "<p>ok</p>"; got != want {
+		t.Errorf("future deadline: got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	err := r.Render(DeadlineWriter(&buf, time.Now().Add(-time.Second)), "page", "late", nil)
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("passed deadline: got error %v, want os.ErrDeadlineExceeded", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("passed deadline: wrote %q, want nothing", buf.String())
+	}
+}
+
+func TestRenderExpiredContext(t *testing.T) {
+	r := newDeadlineRenderer(t, WithRenderTimeout(time.Minute))
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	var buf bytes.Buffer
+	err := RenderContext(ctx, r, &buf, "page", "late", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want context.DeadlineExceeded", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("wrote %q, want nothing", buf.String())
+	}
+}
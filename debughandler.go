@@ -0,0 +1,127 @@
+package tplx
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"time"
+)
+
+// debugTemplate describes a top-level template on the page of DebugHandler.
+type debugTemplate struct {
+	Name      string          `json:"name"`
+	ETag      string          `json:"etag,omitempty"`
+	Funcs     []string        `json:"funcs"`
+	Fragments []debugFragment `json:"fragments"`
+}
+
+// debugFragment describes a fragment on the page of DebugHandler.
+type debugFragment struct {
+	Name     string    `json:"name"`
+	Path     string    `json:"path"`
+	Modified time.Time `json:"modified"`
+	Source   bool      `json:"source"`
+}
+
+var debugPage = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Templates</title></head>
+<body>
+<h1>Templates</h1>
+{{ range . }}
+<h2 id="{{ .Name }}">{{ .Name }}</h2>
+<p>ETag: <code>{{ .ETag }}</code></p>
+<p>Functions: {{ range $i, $f := .Funcs }}{{ if $i }}, {{ end }}<code>{{ $f }}</code>{{ end }}</p>
+<table>
+<tr><th>Fragment</th><th>Path</th><th>Modified</th></tr>
+{{ $name := .Name }}{{ range .Fragments }}
+<tr>
+<td>{{ if .Source }}<a href="?template={{ $name }}&amp;fragment={{ .Name }}">{{ .Name }}</a>{{ else }}{{ .Name }}{{ end }}</td>
+<td><code>{{ .Path }}</code></td>
+<td>{{ if not .Modified.IsZero }}{{ .Modified.Format "2006-01-02 15:04:05" }}{{ end }}</td>
+</tr>
+{{ end }}
+</table>
+{{ end }}
+</body>
+</html>
+`))
+
+// DebugHandler returns a handler listing all top-level templates of r, for
+// diagnostics during development. For each template, it shows its functions,
+// its ETag and its fragments, with their paths and the modification times of
+// their files. If source retention is enabled (see WithSourceRetention),
+// fragments link to their source.
+//
+// The listing is served as HTML, or as JSON if the request prefers
+// application/json or has the query parameter format=json. The handler only
+// responds if r is in debug mode (see WithDebug), and with 404 Not Found
+// otherwise, so it can be registered unconditionally. Renderers not created
+// by this package are answered with 501 Not Implemented.
+func DebugHandler(r Renderer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rr, ok := r.(*renderer)
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+			return
+		}
+		if !rr.c.debug {
+			http.NotFound(w, req)
+			return
+		}
+
+		q := req.URL.Query()
+		if name := q.Get("template"); name != "" {
+			text, err := rr.TemplateSource(name, q.Get("fragment"))
+			if err != nil {
+				http.NotFound(w, req)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = w.Write([]byte(text))
+			return
+		}
+
+		templates := rr.debugTemplates()
+		if q.Get("format") == "json" || acceptQuality(req.Header.Get("Accept"), "application/json") > acceptQuality(req.Header.Get("Accept"), "text/html") {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(templates)
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := debugPage.Execute(&buf, templates); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = buf.WriteTo(w)
+	})
+}
+
+// debugTemplates collects the listing of DebugHandler.
+func (r *renderer) debugTemplates() []debugTemplate {
+	var templates []debugTemplate
+	for _, name := range r.Names() {
+		t := debugTemplate{Name: name, Funcs: r.FuncNames(name)}
+		t.ETag, _ = r.ETag(name)
+
+		// Templates added with CompileTemplate are not part of the spec and
+		// have no fragments to list.
+		metas, _ := resolveExtends(r.spec, name)
+		for _, meta := range metas {
+			f := debugFragment{Name: meta.Name, Path: meta.Path}
+			if info, err := fs.Stat(r.fsys, meta.Path); err == nil {
+				f.Modified = info.ModTime()
+			}
+			_, err := r.TemplateSource(name, meta.Name)
+			f.Source = err == nil
+			t.Fragments = append(t.Fragments, f)
+		}
+
+		templates = append(templates, t)
+	}
+	return templates
+}
@@ -0,0 +1,57 @@
+package tplx
+
+import (
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Default settings applied by NewRendererWithDefaults.
+const (
+	DefaultOutputLimit   = 10 << 20
+	DefaultRenderTimeout = 30 * time.Second
+)
+
+// NewRendererWithDefaults creates a new Renderer like NewRenderer, with a set
+// of defaults suited for production:
+//
+//   - missing map keys are errors (WithMissingKey("error"))
+//   - diagnostics are logged with slog.Default (WithLogger)
+//   - output is limited to DefaultOutputLimit bytes (WithOutputLimit)
+//   - renders time out after DefaultRenderTimeout (WithRenderTimeout)
+//   - output is buffered in pooled buffers (WithBufferPool)
+//   - if the TPLX_METRICS environment variable is 1, render counters are
+//     published with the prefix tplx (WithExpvarMetrics)
+//
+// The opts parameter is applied after the defaults, so each of them can be
+// overridden, e.g. with WithOutputLimit(0).
+//
+// Returns a Renderer instance or an error as described for NewRenderer.
+func NewRendererWithDefaults(fsys fs.FS, spec Spec, funcs template.FuncMap, opts ...Option) (Renderer, error) {
+	defaults := []Option{
+		WithMissingKey("error"),
+		WithLogger(slog.Default()),
+		WithOutputLimit(DefaultOutputLimit),
+		WithRenderTimeout(DefaultRenderTimeout),
+		WithBufferPool(true),
+	}
+	if os.Getenv("TPLX_METRICS") == "1" {
+		defaults = append(defaults, WithExpvarMetrics("tplx"))
+	}
+
+	return NewRenderer(fsys, spec, funcs, append(defaults, opts...)...)
+}
+
+// DevelopmentDefaults returns an Option for use during development, typically
+// passed to NewRendererWithDefaults. It enables debug mode, hot reloading of
+// templates (WithHotReload) and logging of malformed HTML
+// (WithHTMLValidation).
+func DevelopmentDefaults() Option {
+	return func(c *config) {
+		for _, opt := range []Option{WithDebug(true), WithHotReload(), WithHTMLValidation(false)} {
+			opt(c)
+		}
+	}
+}
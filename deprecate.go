@@ -0,0 +1,51 @@
+package tplx
+
+import (
+	"fmt"
+	"html/template"
+	"log/slog"
+	"reflect"
+	"sync/atomic"
+)
+
+// DeprecatedFunc describes a template function that is kept for compatibility
+// during a migration period. Fn is the function itself and Replacement the
+// name of the function templates should use instead, if any.
+type DeprecatedFunc struct {
+	Replacement string
+	Fn          any
+}
+
+// DeprecateFuncs returns template functions that log a warning with
+// slog.Default when they are called and then delegate to the original
+// functions, so that remaining uses of renamed functions can be found before
+// they are removed.
+//
+// Calls are counted per function of the returned map and only the first call
+// of each function is logged, along with the deprecated name and its
+// replacement. Use a separate map per renderer to get a warning for each of
+// them. Since template functions do not see the render context, the warning
+// does not name the calling template.
+//
+// DeprecateFuncs panics if an Fn is not a function.
+func DeprecateFuncs(funcs map[string]DeprecatedFunc) template.FuncMap {
+	m := make(template.FuncMap, len(funcs))
+	for name, d := range funcs {
+		v := reflect.ValueOf(d.Fn)
+		if v.Kind() != reflect.Func {
+			panic(fmt.Sprintf("tplx: deprecated function %q is not a function", name))
+		}
+
+		var calls atomic.Int64
+		m[name] = reflect.MakeFunc(v.Type(), func(args []reflect.Value) []reflect.Value {
+			if calls.Add(1) == 1 {
+				slog.Default().Warn("deprecated template function called", "func", name, "replacement", d.Replacement)
+			}
+			if v.Type().IsVariadic() {
+				return v.CallSlice(args)
+			}
+			return v.Call(args)
+		}).Interface()
+	}
+	return m
+}
@@ -0,0 +1,122 @@
+package tplx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+// EmailRenderer renders emails consisting of an HTML and a plain-text
+// version from the same data.
+type EmailRenderer struct {
+	html Renderer
+	text textRenderer
+}
+
+// NewEmailRenderer creates an EmailRenderer from a file system, specification
+// and global function map.
+//
+// Each top-level template in spec describes one email. Its fragments with
+// Format "html", or no Format, make up the HTML version, which is parsed with
+// html/template by NewRenderer and configured by opts. Its fragments with
+// Format "text" make up the plain-text version, which is parsed with
+// text/template using the delimiters and missingkey mode set by WithDelims
+// and WithMissingKey. Both versions must contain a fragment named like the
+// top-level template. Templates listed with Extends contribute the fragments
+// of the respective format. The funcs parameter provides functions to both
+// versions.
+//
+// Returns an EmailRenderer or an error if the templates cannot be initialized
+// according to the specification.
+func NewEmailRenderer(fsys fs.FS, spec Spec, funcs template.FuncMap, opts ...Option) (*EmailRenderer, error) {
+	if err := validateSpec(spec, true); err != nil {
+		return nil, err
+	}
+
+	htmlSpec := make(Spec, len(spec))
+	textSpec := make(Spec, len(spec))
+	for name, metas := range spec {
+		htmlSpec[name], textSpec[name] = nil, nil
+		for _, meta := range metas {
+			switch {
+			case meta.Extends != "":
+				htmlSpec[name] = append(htmlSpec[name], meta)
+				textSpec[name] = append(textSpec[name], meta)
+			case meta.Format == FormatText:
+				textSpec[name] = append(textSpec[name], meta)
+			default:
+				htmlSpec[name] = append(htmlSpec[name], meta)
+			}
+		}
+	}
+
+	html, err := NewRenderer(fsys, htmlSpec, funcs, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	c := newConfig(opts)
+	text := textRenderer{m: make(map[string]*texttemplate.Template, len(textSpec))}
+	for name := range textSpec {
+		t, err := parseText(c, fsys, textSpec, name, texttemplate.FuncMap(funcs))
+		if err != nil {
+			return nil, fmt.Errorf("text version of %q: %w", name, err)
+		}
+		text.m[name] = t
+	}
+
+	return &EmailRenderer{html: html, text: text}, nil
+}
+
+// RenderEmail renders both versions of the email name with the same data and
+// functions.
+//
+// Returns ErrUnknownTemplate if the email does not exist, or an error if
+// either version cannot be rendered.
+func (er *EmailRenderer) RenderEmail(name string, data any, funcs template.FuncMap) (html, text string, err error) {
+	var hb, tb bytes.Buffer
+	if err := er.html.Render(&hb, name, data, funcs); err != nil {
+		return "", "", err
+	}
+	if err := er.text.Render(&tb, name, data, funcs); err != nil {
+		return "", "", err
+	}
+	return hb.String(), tb.String(), nil
+}
+
+// RenderEmailSubject renders the subject line of the email name from the
+// sub-template named name + "/subject", with surrounding whitespace trimmed.
+// The subject is a fragment of the email like any other, and is looked up in
+// the plain-text version first. If it is only part of the HTML version, the
+// rendered HTML is unescaped, so that the subject is plain text either way.
+//
+// Returns ErrUnknownTemplate if the email or its subject does not exist, or an
+// error if the subject cannot be rendered.
+func (er *EmailRenderer) RenderEmailSubject(name string, data any, funcs template.FuncMap) (string, error) {
+	block := name + "/subject"
+
+	var buf bytes.Buffer
+	err := er.text.RenderBlock(&buf, name, block, data, funcs)
+	if err == nil {
+		return strings.TrimSpace(buf.String()), nil
+	}
+	if !errors.Is(err, ErrUnknownTemplate) {
+		return "", err
+	}
+
+	br, ok := er.html.(BlockRenderer)
+	if !ok {
+		return "", ErrUnknownTemplate
+	}
+
+	buf.Reset()
+	if err := br.RenderBlock(&buf, name, block, data, funcs); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(html.UnescapeString(buf.String())), nil
+}
@@ -0,0 +1,124 @@
+package tplx
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+var emailFS = fstest.MapFS{
+	"welcome.html": {Data: []byte(`{{define "welcome/subject"}} Welcome, {{.}} {{end}}<p>Hello {{.}}</p>`)},
+	"welcome.txt":  {Data: []byte(`Hello {{.}}`)},
+	"reset.html":   {Data: []byte(`{{define "reset/subject"}} Reset for {{.}} {{end}}<p>Reset {{.}}</p>`)},
+	"reset.txt":    {Data: []byte(`{{define "reset/subject"}} Password reset {{end}}Reset {{.}}`)},
+}
+
+var emailSpec = Spec{
+	"welcome": {
+		{Name: "welcome", Path: "welcome.html"},
+		{Name: "welcome", Path: "welcome.txt", Format: FormatText},
+	},
+	"reset": {
+		{Name: "reset", Path: "reset.html"},
+		{Name: "reset", Path: "reset.txt", Format: FormatText},
+	},
+}
+
+func TestRenderEmail(t *testing.T) {
+	er, err := NewEmailRenderer(emailFS, emailSpec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	html, text, err := er.RenderEmail("welcome", "<Ann>", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<p>Hello &lt;Ann&gt;</p>"; html != want {
+		t.Errorf("html: got %q, want %q", html, want)
+	}
+	if want := "Hello <Ann>"; text != want {
+		t.Errorf("text: got %q, want %q", text, want)
+	}
+
+	if _, _, err := er.RenderEmail("missing", nil, nil); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("missing email: got %v, want ErrUnknownTemplate", err)
+	}
+}
+
+func TestRenderEmailSubject(t *testing.T) {
+	er, err := NewEmailRenderer(emailFS, emailSpec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		email   string
+		want    string
+		wantErr error
+	}{
+		{name: "text version", email: "reset", want: "Password reset"},
+		{name: "html version only", email: "welcome", want: "Welcome, <Ann>"},
+		{name: "unknown email", email: "missing", wantErr: ErrUnknownTemplate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := er.RenderEmailSubject(tt.email, "<Ann>", nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewEmailRendererRejectsDuplicates(t *testing.T) {
+	spec := Spec{"welcome": {
+		{Name: "welcome", Path: "welcome.txt", Format: FormatText},
+		{Name: "welcome", Path: "reset.txt", Format: FormatText},
+	}}
+	if _, err := NewEmailRenderer(emailFS, spec, nil); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("got %v, want ErrInvalidSpec", err)
+	}
+}
+
+func TestValidateSpecIgnoresFormat(t *testing.T) {
+	// NewRenderer parses every fragment with html/template, so a text fragment
+	// with the same name would replace the HTML one.
+	if err := ValidateSpec(emailSpec); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("ValidateSpec: got %v, want ErrInvalidSpec", err)
+	}
+	if _, err := NewRenderer(emailFS, emailSpec, nil); !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("NewRenderer: got %v, want ErrInvalidSpec", err)
+	}
+}
+
+func TestEmailRendererDelimsAndMissingKey(t *testing.T) {
+	fsys := fstest.MapFS{
+		"welcome.html": {Data: []byte(`<p>[[.name]]</p>`)},
+		"welcome.txt":  {Data: []byte(`Hello [[.name]][[.missing]]`)},
+	}
+	spec := Spec{"welcome": {
+		{Name: "welcome", Path: "welcome.html"},
+		{Name: "welcome", Path: "welcome.txt", Format: FormatText},
+	}}
+	er, err := NewEmailRenderer(fsys, spec, nil, WithDelims("[[", "]]"), WithMissingKey("zero"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// With missingkey=zero, a missing key of map[string]string yields "".
+	html, text, err := er.RenderEmail("welcome", map[string]string{"name": "Ann"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<p>Ann</p>"; html != want {
+		t.Errorf("html: got %q, want %q", html, want)
+	}
+	if want := "Hello Ann"; text != want {
+		t.Errorf("text: got %q, want %q", text, want)
+	}
+}
@@ -0,0 +1,26 @@
+package tplx
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+)
+
+// NewRendererFromEmbed creates a new Renderer from an embedded file system.
+//
+// Paths in an embed.FS are relative to the directory containing the embed
+// directive and include the embedded directory itself. The root parameter
+// specifies the directory within fsys that Meta paths in spec are relative to,
+// so that they do not have to repeat it. The remaining parameters are the same
+// as for NewRenderer.
+//
+// Returns a Renderer instance or an error if root is invalid or the templates
+// cannot be initialized according to the specification.
+func NewRendererFromEmbed(fsys embed.FS, root string, spec Spec, funcs template.FuncMap, opts ...Option) (Renderer, error) {
+	sub, err := fs.Sub(fsys, root)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open embedded directory: %w", err)
+	}
+	return NewRenderer(sub, spec, funcs, opts...)
+}
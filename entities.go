@@ -0,0 +1,160 @@
+package tplx
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strconv"
+	"unicode/utf8"
+)
+
+// EntityError describes an invalid character reference in rendered output.
+// Offset is the byte offset of the reference and Ref its text.
+type EntityError struct {
+	Template string
+	Offset   int
+	Ref      string
+}
+
+func (e *EntityError) Error() string {
+	return fmt.Sprintf("template %q: invalid character reference %q at offset %d", e.Template, e.Ref, e.Offset)
+}
+
+// WithEntityValidation corrects invalid character references in rendered
+// output, which strict XML parsers reject, using
+// EntityPostProcessor(false).
+func WithEntityValidation() Option {
+	return WithPostProcessor(EntityPostProcessor(false))
+}
+
+// EntityPostProcessor returns a PostProcessor that checks the character
+// references of HTML output. A reference is invalid if it lacks its closing
+// semicolon, as in &copy, names an unknown entity, or refers to a code point
+// that is not a valid character, as in &#999999;. An ampersand that does not
+// start a reference is invalid as well. The contents of script and style
+// elements are skipped.
+//
+// In strict mode the first invalid reference fails the render with an
+// *EntityError. Otherwise the ampersands of invalid references are escaped as
+// &amp;, except for numeric references to invalid code points, which are
+// replaced by &#xFFFD;.
+func EntityPostProcessor(strict bool) PostProcessor {
+	return FuncPostProcessor(func(name string, input []byte) ([]byte, error) {
+		if bytes.IndexByte(input, '&') < 0 {
+			return input, nil
+		}
+
+		out := make([]byte, 0, len(input))
+		for i := 0; i < len(input); {
+			if input[i] == '<' {
+				if end := rawTextEnd(input, i); end > i {
+					out = append(out, input[i:end]...)
+					i = end
+					continue
+				}
+			}
+
+			if input[i] != '&' {
+				out = append(out, input[i])
+				i++
+				continue
+			}
+
+			n, ok, numeric := scanReference(input[i:])
+			if ok {
+				out = append(out, input[i:i+n]...)
+				i += n
+				continue
+			}
+
+			if strict {
+				return nil, &EntityError{Template: name, Offset: i, Ref: string(input[i : i+max(n, 1)])}
+			}
+
+			if numeric {
+				out = append(out, "&#xFFFD;"...)
+				i += n
+			} else {
+				out = append(out, "&amp;"...)
+				i++
+			}
+		}
+		return out, nil
+	})
+}
+
+// scanReference scans the character reference at the start of b, which
+// starts with an ampersand. It returns the length of the reference, whether
+// it is valid, and whether it is a complete numeric reference to an invalid
+// code point.
+func scanReference(b []byte) (n int, ok, numeric bool) {
+	i := 1
+	if i < len(b) && b[i] == '#' {
+		i++
+		base := 10
+		if i < len(b) && (b[i] == 'x' || b[i] == 'X') {
+			base = 16
+			i++
+		}
+
+		start := i
+		for i < len(b) && isDigit(b[i], base) {
+			i++
+		}
+		if i == start || i >= len(b) || b[i] != ';' {
+			return i, false, false
+		}
+
+		cp, err := strconv.ParseUint(string(b[start:i]), base, 32)
+		if err != nil || cp == 0 || !utf8.ValidRune(rune(cp)) {
+			return i + 1, false, true
+		}
+		return i + 1, true, false
+	}
+
+	for i < len(b) && isAlnum(b[i]) {
+		i++
+	}
+	if i == 1 || i >= len(b) || b[i] != ';' {
+		return i, false, false
+	}
+
+	ref := string(b[:i+1])
+	return i + 1, html.UnescapeString(ref) != ref, false
+}
+
+// rawTextEnd returns the offset after the script or style element starting at
+// offset i of b, or i if there is none.
+func rawTextEnd(b []byte, i int) int {
+	for _, tag := range []string{"script", "style"} {
+		open := "<" + tag
+		if len(b)-i <= len(open) || !bytes.EqualFold(b[i:i+len(open)], []byte(open)) {
+			continue
+		}
+		if c := b[i+len(open)]; c != '>' && c != ' ' && c != '\t' && c != '\n' && c != '/' {
+			continue
+		}
+
+		rest := b[i:]
+		closing := bytes.Index(bytes.ToLower(rest), []byte("</"+tag))
+		if closing < 0 {
+			return len(b)
+		}
+		return i + closing
+	}
+	return i
+}
+
+func isDigit(c byte, base int) bool {
+	switch {
+	case '0' <= c && c <= '9':
+		return true
+	case base == 16 && ('a' <= c && c <= 'f' || 'A' <= c && c <= 'F'):
+		return true
+	}
+	return false
+}
+
+func isAlnum(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
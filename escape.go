@@ -0,0 +1,115 @@
+package tplx
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+	"unicode/utf16"
+)
+
+// ContextualEscapeFuncs returns template functions that escape a string for a
+// specific context and mark the result as safe for that context, so that
+// html/template does not escape it a second time.
+//
+// The returned map contains the following functions:
+//
+//   - jsEscape(s string) template.JSStr escapes s for use as the content of a
+//     JavaScript string literal, quoted with either quote character, inside a
+//     script element or an event handler attribute. Used outside of a string
+//     literal, html/template adds the quotes itself. Every character other
+//     than ASCII letters, digits and spaces is written as a \u escape.
+//   - cssEscape(s string) template.CSS escapes s for use as a CSS identifier
+//     or unquoted value, as in color: {{cssEscape .Color}}. Inside a quoted
+//     CSS string, pass the plain string instead, which html/template escapes
+//     for that context; cssEscape would be escaped a second time there.
+//   - urlEscape(s string) template.URL escapes s for use as a URL query
+//     component.
+//   - htmlAttr(name, value string) (template.HTMLAttr, error) returns a whole
+//     name="value" attribute with value escaped, for use where an attribute
+//     is expected, as in <input {{htmlAttr "placeholder" .Hint}}>. Since the
+//     result is trusted as is, names that are not plain attribute names and
+//     attributes holding scripts, styles or URLs are rejected; write those
+//     directly in the template so that html/template escapes them.
+func ContextualEscapeFuncs() template.FuncMap {
+	return template.FuncMap{
+		"jsEscape":  jsEscape,
+		"cssEscape": cssEscape,
+		"urlEscape": urlEscape,
+		"htmlAttr":  htmlAttr,
+	}
+}
+
+// WithContextualEscaping registers the functions of ContextualEscapeFuncs as
+// global template functions.
+func WithContextualEscaping() Option {
+	return func(c *config) {
+		c.addFuncs(ContextualEscapeFuncs())
+	}
+}
+
+func jsEscape(s string) template.JSStr {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == ' ':
+			b.WriteRune(r)
+		case r > 0xffff:
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&b, "\\u%04x\\u%04x", r1, r2)
+		default:
+			fmt.Fprintf(&b, "\\u%04x", r)
+		}
+	}
+	return template.JSStr(b.String())
+}
+
+func cssEscape(s string) template.CSS {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r > 0x7f:
+			b.WriteRune(r)
+		default:
+			// A trailing space terminates the hexadecimal escape.
+			fmt.Fprintf(&b, "\\%x ", r)
+		}
+	}
+	return template.CSS(b.String())
+}
+
+func urlEscape(s string) template.URL {
+	return template.URL(url.QueryEscape(s))
+}
+
+func htmlAttr(name, value string) (template.HTMLAttr, error) {
+	if !safeAttrName(name) {
+		return "", fmt.Errorf("htmlAttr: unsafe attribute name %q", name)
+	}
+	return template.HTMLAttr(name + `="` + template.HTMLEscapeString(value) + `"`), nil
+}
+
+// safeAttrName reports whether name is a plain attribute name whose value
+// html/template does not treat as a script, style or URL.
+func safeAttrName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+
+	name = strings.ToLower(name)
+	if strings.HasPrefix(name, "on") || strings.Contains(name, "src") || strings.Contains(name, "uri") || strings.Contains(name, "url") {
+		return false
+	}
+	switch name {
+	case "style", "href", "action", "formaction", "background", "cite", "codebase", "data", "longdesc", "manifest", "poster", "profile", "usemap", "xmlns":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package tplx
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestContextualEscapeFuncs(t *testing.T) {
+	// Each escaped form must decode to payload exactly once.
+	const payload = `a'b";alert(1)//</script>`
+
+	for _, tc := range []struct {
+		name, src, want string
+	}{
+		{
+			name: "js double-quoted string",
+			src:  `<script>var s = "{{jsEscape .}}";</script>`,
+			want: `<script>var s = "a\u0027b\u0022\u003balert\u00281\u0029\u002f\u002f\u003c\u002fscript\u003e";</script>`,
+		},
+		{
+			name: "js single-quoted string",
+			src:  `<script>var s = '{{jsEscape .}}';</script>`,
+			want: `<script>var s = 'a\u0027b\u0022\u003balert\u00281\u0029\u002f\u002f\u003c\u002fscript\u003e';</script>`,
+		},
+		{
+			name: "js value",
+			src:  `<script>var s = {{jsEscape .}};</script>`,
+			want: `<script>var s = "a\u0027b\u0022\u003balert\u00281\u0029\u002f\u002f\u003c\u002fscript\u003e";</script>`,
+		},
+		{
+			name: "js event handler attribute",
+			src:  `<button onclick="f('{{jsEscape .}}')">`,
+			want: `<button onclick="f('a\u0027b\u0022\u003balert\u00281\u0029\u002f\u002f\u003c\u002fscript\u003e')">`,
+		},
+		{
+			name: "css value",
+			src:  `<p style="font-family: {{cssEscape .}}">`,
+			want: `<p style="font-family: a\27 b\22 \3b alert\28 1\29 \2f \2f \3c \2f script\3e ">`,
+		},
+		{
+			name: "css value in style element",
+			src:  `<style>.x { font-family: {{cssEscape .}} }</style>`,
+			want: `<style>.x { font-family: a\27 b\22 \3b alert\28 1\29 \2f \2f \3c \2f script\3e  }</style>`,
+		},
+		{
+			name: "url query",
+			src:  `<a href="/search?q={{urlEscape .}}">`,
+			want: `<a href="/search?q=a%27b%22%3Balert%281%29%2F%2F%3C%2Fscript%3E">`,
+		},
+		{
+			name: "attribute",
+			src:  `<input {{htmlAttr "placeholder" .}}>`,
+			want: `<input placeholder="a&#39;b&#34;;alert(1)//&lt;/script&gt;">`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl := template.Must(template.New("").Funcs(ContextualEscapeFuncs()).Parse(tc.src))
+			var sb strings.Builder
+			if err := tmpl.Execute(&sb, payload); err != nil {
+				t.Fatal(err)
+			}
+			if got := sb.String(); got != tc.want {
+				t.Errorf("got  %s\nwant %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestJSEscapeSupplementary(t *testing.T) {
+	if got, want := string(jsEscape("x😀")), `x\ud83d\ude00`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestHTMLAttrRejectsUnsafeNames(t *testing.T) {
+	for _, name := range []string{"onclick", "href", "src", "style", "formaction", "data-x=y", ""} {
+		if _, err := htmlAttr(name, "v"); err == nil {
+			t.Errorf("htmlAttr(%q): got no error", name)
+		}
+	}
+	for _, name := range []string{"placeholder", "title", "data-id", "aria-label"} {
+		if _, err := htmlAttr(name, "v"); err != nil {
+			t.Errorf("htmlAttr(%q): %v", name, err)
+		}
+	}
+}
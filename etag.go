@@ -0,0 +1,65 @@
+package tplx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// ETag returns an entity tag identifying the current version of the
+// top-level template name, derived from the text of all its fragments. It
+// changes whenever the template is reloaded, patched or compiled with
+// different text. The tag does not depend on any data, so it only identifies
+// the rendered output together with the data it is rendered with.
+//
+// Returns ErrUnknownTemplate if the template does not exist.
+func (r *renderer) ETag(name string) (string, error) {
+	e, ok := r.lookup(name)
+	if !ok {
+		return "", ErrUnknownTemplate
+	}
+	return `"` + e.etag + `"`, nil
+}
+
+// LastModified returns the latest modification time of the files making up
+// the top-level template name, including the files of templates it extends.
+// Templates compiled from memory, and file systems that do not record
+// modification times, such as embed.FS, yield the zero time.
+//
+// Returns ErrUnknownTemplate if the template does not exist, or an error if a
+// file cannot be inspected.
+func (r *renderer) LastModified(name string) (time.Time, error) {
+	if _, ok := r.lookup(name); !ok {
+		return time.Time{}, ErrUnknownTemplate
+	}
+	if _, ok := r.spec[name]; !ok {
+		return time.Time{}, nil
+	}
+
+	metas, err := resolveExtends(r.spec, name)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var latest time.Time
+	for _, meta := range metas {
+		info, err := fs.Stat(r.fsys, meta.Path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if t := info.ModTime(); t.After(latest) {
+			latest = t
+		}
+	}
+	return latest, nil
+}
+
+// patchedETag returns the entity tag of a template with the tag etag after
+// replacing the sub-template name with text.
+func patchedETag(etag, name, text string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %q %q\n", etag, name, text)
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
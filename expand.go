@@ -0,0 +1,30 @@
+package tplx
+
+import (
+	"os"
+	"regexp"
+)
+
+var expandPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// WithEnvSubstitution replaces references of the form ${NAME} in template
+// files with the value of the environment variable NAME before parsing.
+// Unset variables are replaced by the empty string.
+//
+// Unlike os.ExpandEnv, the unbraced form $NAME is left alone, since it would
+// clash with template variables such as {{$x}}.
+func WithEnvSubstitution() Option {
+	return WithCustomExpander(os.Getenv)
+}
+
+// WithCustomExpander is like WithEnvSubstitution but replaces references of the
+// form ${NAME} by the value fn returns for NAME, e.g. from a configuration map.
+func WithCustomExpander(fn func(string) string) Option {
+	return func(c *config) {
+		c.transforms = append(c.transforms, func(text []byte) []byte {
+			return expandPattern.ReplaceAllFunc(text, func(ref []byte) []byte {
+				return []byte(fn(string(ref[2 : len(ref)-1])))
+			})
+		})
+	}
+}
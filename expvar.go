@@ -0,0 +1,72 @@
+package tplx
+
+import (
+	"context"
+	"expvar"
+	"html/template"
+	"io"
+	"sync"
+	"time"
+)
+
+// WithExpvarMetrics publishes render counters through the expvar package,
+// making them visible at the standard /debug/vars endpoint.
+//
+// The following variables are registered using the given prefix:
+// prefix_renders_total, prefix_render_errors_total and
+// prefix_render_duration_ms_total. Variables that are already registered under
+// the same name are reused, so multiple renderers may share a prefix. If a
+// name is already taken by a variable of a different type, the counter is
+// kept but not published.
+func WithExpvarMetrics(prefix string) Option {
+	renders := expvarInt(prefix + "_renders_total")
+	errs := expvarInt(prefix + "_render_errors_total")
+	duration := expvarFloat(prefix + "_render_duration_ms_total")
+
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, func(next RenderHandler) RenderHandler {
+			return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+				start := time.Now()
+				err := next(ctx, w, name, data, funcs)
+				duration.Add(float64(time.Since(start)) / float64(time.Millisecond))
+				renders.Add(1)
+				if err != nil {
+					errs.Add(1)
+				}
+				return err
+			}
+		})
+	}
+}
+
+// expvarMu serializes looking up and registering variables, since
+// expvar.Publish panics if another caller registered the name in between.
+var expvarMu sync.Mutex
+
+func expvarInt(name string) *expvar.Int {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	switch v := expvar.Get(name).(type) {
+	case nil:
+		return expvar.NewInt(name)
+	case *expvar.Int:
+		return v
+	default:
+		return new(expvar.Int)
+	}
+}
+
+func expvarFloat(name string) *expvar.Float {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	switch v := expvar.Get(name).(type) {
+	case nil:
+		return expvar.NewFloat(name)
+	case *expvar.Float:
+		return v
+	default:
+		return new(expvar.Float)
+	}
+}
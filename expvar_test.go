@@ -0,0 +1,93 @@
+package tplx
+
+import (
+	"expvar"
+	"io"
+	"testing"
+	"testing/fstest"
+)
+
+func TestWithExpvarMetrics(t *testing.T) {
+	fsys := fstest.MapFS{"page.html": {Data: []byte("{{.Title}}")}}
+	spec := Spec{"page": {{Name: "page", Path: "page.html"}}}
+
+	// Two renderers share the prefix, and so the counters.
+	var rs []Renderer
+	for range 2 {
+		r, err := NewRenderer(fsys, spec, nil, WithExpvarMetrics("test_expvar"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs = append(rs, r)
+	}
+
+	// The variables outlive the test, so only their increase is checked.
+	before := map[string]int64{
+		"test_expvar_renders_total":       expvarInt("test_expvar_renders_total").Value(),
+		"test_expvar_render_errors_total": expvarInt("test_expvar_render_errors_total").Value(),
+	}
+
+	for _, r := range rs {
+		if err := r.Render(io.Discard, "page", map[string]string{"Title": "Home"}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rs[0].Render(io.Discard, "missing", nil, nil); err == nil {
+		t.Fatal("rendering missing template: got no error")
+	}
+
+	for name, want := range map[string]int64{
+		"test_expvar_renders_total":       3,
+		"test_expvar_render_errors_total": 1,
+	} {
+		v, ok := expvar.Get(name).(*expvar.Int)
+		if !ok {
+			t.Errorf("%s: not published as *expvar.Int", name)
+			continue
+		}
+		if got := v.Value() - before[name]; got != want {
+			t.Errorf("%s increased by %d, want %d", name, got, want)
+		}
+	}
+	if v, ok := expvar.Get("test_expvar_render_duration_ms_total").(*expvar.Float); !ok || v.Value() < 0 {
+		t.Errorf("test_expvar_render_duration_ms_total: got %v", expvar.Get("test_expvar_render_duration_ms_total"))
+	}
+}
+
+func TestWithExpvarMetricsTypeConflict(t *testing.T) {
+	if expvar.Get("test_conflict_renders_total") == nil {
+		expvar.NewString("test_conflict_renders_total").Set("taken")
+	}
+
+	fsys := fstest.MapFS{"page.html": {Data: []byte("ok")}}
+	r, err := NewRenderer(fsys, Spec{"page": {{Name: "page", Path: "page.html"}}}, nil, WithExpvarMetrics("test_conflict"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Render(io.Discard, "page", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := expvar.Get("test_conflict_renders_total").String(); got != `"taken"` {
+		t.Errorf("conflicting variable = %s, want it unchanged", got)
+	}
+	if _, ok := expvar.Get("test_conflict_render_errors_total").(*expvar.Int); !ok {
+		t.Errorf("test_conflict_render_errors_total: got %v", expvar.Get("test_conflict_render_errors_total"))
+	}
+}
+
+func TestWithExpvarMetricsConcurrent(t *testing.T) {
+	done := make(chan *expvar.Int)
+	for range 8 {
+		go func() {
+			done <- expvarInt("test_concurrent_total")
+		}()
+	}
+
+	first := <-done
+	for range 7 {
+		if v := <-done; v != first {
+			t.Fatal("concurrent registrations returned different variables")
+		}
+	}
+}
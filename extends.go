@@ -0,0 +1,124 @@
+package tplx
+
+import (
+	"fmt"
+	"slices"
+)
+
+// resolveExtends returns the fragments of the top-level template name with
+// all extended templates resolved. The templates are visited in reverse
+// inheritance order (see ResolveInheritanceOrder), so that fragments of
+// extended templates come first and each template can redefine the blocks and
+// override the functions of the templates it extends. A template extended
+// through several paths contributes its fragments only once.
+func resolveExtends(spec Spec, name string) ([]Meta, error) {
+	order, err := linearize(spec, name, make(map[string]bool), make(map[string][]string))
+	if err != nil {
+		return nil, err
+	}
+
+	var metas []Meta
+	for _, t := range slices.Backward(order) {
+		for _, meta := range spec[t] {
+			if meta.Extends == "" {
+				metas = append(metas, meta)
+			}
+		}
+	}
+	return metas, nil
+}
+
+// ResolveInheritanceOrder computes the inheritance order of every top-level
+// template in spec that uses Extends, using C3 linearization as known from
+// Python's method resolution order. The order starts with the template itself,
+// followed by the templates it extends, directly or indirectly, from the most
+// to the least specific. When a template extends several templates, those
+// listed earlier take precedence, and a template extended through several
+// paths, as in a diamond, appears only once, after all templates extending it.
+//
+// NewRenderer merges the fragments of a template in reverse of this order, so
+// that blocks of templates earlier in the order win.
+//
+// Returns an error wrapping ErrInvalidSpec if a template extends an unknown
+// template, the Extends graph has a cycle, or no consistent order exists.
+func ResolveInheritanceOrder(spec Spec) (map[string][]string, error) {
+	memo := make(map[string][]string)
+	orders := make(map[string][]string)
+	for name, metas := range spec {
+		if !slices.ContainsFunc(metas, func(m Meta) bool { return m.Extends != "" }) {
+			continue
+		}
+
+		order, err := linearize(spec, name, make(map[string]bool), memo)
+		if err != nil {
+			return nil, err
+		}
+		orders[name] = slices.Clone(order)
+	}
+	return orders, nil
+}
+
+// linearize returns the C3 linearization of the top-level template name.
+func linearize(spec Spec, name string, visiting map[string]bool, memo map[string][]string) ([]string, error) {
+	if order, ok := memo[name]; ok {
+		return order, nil
+	}
+
+	if visiting[name] {
+		return nil, fmt.Errorf("%w: %q extends itself", ErrInvalidSpec, name)
+	}
+
+	metas, ok := spec[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: cannot extend unknown template %q", ErrInvalidSpec, name)
+	}
+
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	var parents []string
+	var seqs [][]string
+	for _, meta := range metas {
+		if meta.Extends == "" {
+			continue
+		}
+
+		order, err := linearize(spec, meta.Extends, visiting, memo)
+		if err != nil {
+			return nil, err
+		}
+		parents = append(parents, meta.Extends)
+		seqs = append(seqs, slices.Clone(order))
+	}
+	seqs = append(seqs, parents)
+
+	order := []string{name}
+	for {
+		seqs = slices.DeleteFunc(seqs, func(s []string) bool { return len(s) == 0 })
+		if len(seqs) == 0 {
+			break
+		}
+
+		var head string
+		found := false
+		for _, s := range seqs {
+			if !slices.ContainsFunc(seqs, func(other []string) bool { return slices.Contains(other[1:], s[0]) }) {
+				head, found = s[0], true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: inconsistent inheritance order for %q", ErrInvalidSpec, name)
+		}
+
+		order = append(order, head)
+		for i, s := range seqs {
+			if s[0] == head {
+				seqs[i] = s[1:]
+			}
+		}
+	}
+
+	memo[name] = order
+	return order, nil
+}
@@ -0,0 +1,150 @@
+package tplx
+
+import (
+	"bytes"
+	"embed"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"maps"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed feeds
+var feedFS embed.FS
+
+// Feed is the data expected by the bundled "atom" and "rss" feed templates.
+//
+// ID identifies the feed and defaults to Link. Description is only used by
+// RSS.
+type Feed struct {
+	Title       string
+	Link        string
+	ID          string
+	Description string
+	Updated     time.Time
+	Entries     []FeedEntry
+}
+
+// FeedEntry is a single entry of a Feed. ID defaults to Link.
+type FeedEntry struct {
+	Title   string
+	Link    string
+	ID      string
+	Content string
+	Updated time.Time
+}
+
+// FeedFuncMap returns the functions available to feed templates:
+//
+//   - rfc3339(t time.Time) string formats t as used by Atom.
+//   - rfc1123(t time.Time) string formats t as used by RSS.
+//   - xmlEscape(s string) string escapes s for use in XML text and attributes.
+//   - atomEntry(title, link, content string) FeedEntry constructs an entry.
+func FeedFuncMap() template.FuncMap {
+	return template.FuncMap{
+		"rfc3339": func(t time.Time) string {
+			return t.UTC().Format(time.RFC3339)
+		},
+		"rfc1123": func(t time.Time) string {
+			return t.UTC().Format(time.RFC1123Z)
+		},
+		"xmlEscape": func(s string) string {
+			var b strings.Builder
+			_ = xml.EscapeText(&b, []byte(s))
+			return b.String()
+		},
+		"atomEntry": func(title, link, content string) FeedEntry {
+			return FeedEntry{Title: title, Link: link, Content: content}
+		},
+	}
+}
+
+type feedRenderer struct {
+	textRenderer
+}
+
+// NewFeedRenderer creates a Renderer for Atom and RSS feeds from a file system
+// and specification.
+//
+// Unlike NewRenderer, it parses templates with text/template, since the
+// contextual escaping of html/template does not apply to XML, and values must
+// be escaped explicitly with xmlEscape. The functions of FeedFuncMap are
+// available to all templates. Unless spec defines them itself, the top-level
+// templates "atom" and "rss" are provided, which render a Feed.
+//
+// Rendered output is checked to be well-formed XML before it is written, so a
+// broken feed is never served.
+//
+// Returns a Renderer instance or an error if the templates cannot be
+// initialized according to the specification.
+func NewFeedRenderer(fsys fs.FS, spec Spec) (Renderer, error) {
+	if err := ValidateSpec(spec); err != nil {
+		return nil, err
+	}
+
+	bundled := Spec{
+		"atom": {{Name: "atom", Path: "feeds/atom.xml.tmpl"}},
+		"rss":  {{Name: "rss", Path: "feeds/rss.xml.tmpl"}},
+	}
+
+	r := feedRenderer{textRenderer{m: make(map[string]*template.Template, len(spec)+len(bundled))}}
+
+	for name := range maps.Keys(bundled) {
+		if _, ok := spec[name]; ok {
+			continue
+		}
+		t, err := parseText(&config{}, feedFS, bundled, name, FeedFuncMap())
+		if err != nil {
+			return nil, err
+		}
+		r.m[name] = t
+	}
+
+	for name := range spec {
+		t, err := parseText(&config{}, fsys, spec, name, FeedFuncMap())
+		if err != nil {
+			return nil, err
+		}
+		r.m[name] = t
+	}
+
+	return r, nil
+}
+
+// containsFragment reports whether metas contains a fragment named name.
+func containsFragment(metas []Meta, name string) bool {
+	for _, meta := range metas {
+		if meta.Extends == "" && meta.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Render writes the rendered feed to w if it is well-formed XML.
+func (r feedRenderer) Render(w io.Writer, name string, data any, funcs htmltemplate.FuncMap) error {
+	var buf bytes.Buffer
+	if err := r.textRenderer.Render(&buf, name, data, funcs); err != nil {
+		return err
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+	for {
+		_, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("rendered feed is not well-formed XML: %w", err)
+		}
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
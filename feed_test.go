@@ -0,0 +1,66 @@
+package tplx
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// goldenFeed has titles, links and content that need escaping.
+var goldenFeed = Feed{
+	Title:       `News & "Notes" <weekly>`,
+	Link:        "https://example.com/?a=1&b=2",
+	Description: "Things that happened & didn't",
+	Updated:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	Entries: []FeedEntry{
+		{
+			Title:   "Tom & Jerry's <b>adventure</b>",
+			Link:    "https://example.com/posts/1?ref=feed&x=<y>",
+			Content: `<p class="intro">Hello &amp; welcome</p>`,
+			Updated: time.Date(2024, 4, 30, 8, 30, 0, 0, time.FixedZone("CEST", 2*60*60)),
+		},
+		{
+			Title:   "Plain",
+			Link:    "https://example.com/posts/2",
+			ID:      "urn:uuid:1b4e28ba-2fa1-11d2-883f-0016d3cca427",
+			Content: "No markup",
+			Updated: time.Date(2024, 4, 29, 0, 0, 0, 0, time.UTC),
+		},
+	},
+}
+
+func TestFeedGolden(t *testing.T) {
+	r, err := NewFeedRenderer(os.DirFS("testdata"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"atom", "rss"} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := r.Render(&buf, name, goldenFeed, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", "feed", name+".xml.golden")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.Bytes(); !bytes.Equal(got, want) {
+				t.Errorf("output differs from %s:\ngot:\n%s\nwant:\n%s", golden, got, want)
+			}
+		})
+	}
+}
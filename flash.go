@@ -0,0 +1,28 @@
+package tplx
+
+import "net/http"
+
+// FlashKey is the data key under which flash messages are injected.
+const FlashKey = "flashes"
+
+// FlashMessage is a one-time message stored in a session, such as a
+// confirmation shown after submitting a form.
+type FlashMessage struct {
+	Type string
+	Text string
+}
+
+// WithFlashExtractor injects flash messages into the template data under
+// FlashKey.
+//
+// The fn parameter extracts the messages from the request, typically by
+// reading and clearing them from a session, which keeps tplx independent of
+// any particular session library. The request is taken from the render context
+// (see NewRequestContext); renders without a request are left untouched.
+// Messages can only be injected into nil data or data of type map[string]any;
+// for other data, fn is not called, so the messages stay in the session.
+func WithFlashExtractor(fn func(r *http.Request) []FlashMessage) Option {
+	return injectFromRequest(FlashKey, func(r *http.Request) any {
+		return fn(r)
+	})
+}
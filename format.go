@@ -0,0 +1,101 @@
+package tplx
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownFormat is returned when no renderer is registered for a format.
+var ErrUnknownFormat = errors.New("no renderer registered for format")
+
+// Formats returned by FormatFromRequest.
+const (
+	FormatHTML = "html"
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// formatMediaTypes maps the media types understood by FormatFromRequest to
+// their format.
+var formatMediaTypes = map[string]string{
+	"text/html":             FormatHTML,
+	"application/xhtml+xml": FormatHTML,
+	"text/plain":            FormatText,
+	"application/json":      FormatJSON,
+}
+
+// WithFormatRenderer registers r as the renderer for format in a
+// MultiFormatRenderer.
+func WithFormatRenderer(format string, r Renderer) Option {
+	return func(c *config) {
+		if c.formats == nil {
+			c.formats = make(map[string]Renderer)
+		}
+		c.formats[format] = r
+	}
+}
+
+// MultiFormatRenderer serves the same content in several formats, such as
+// HTML for browsers, plain text for emails and JSON for APIs, by dispatching
+// to a separate renderer per format.
+type MultiFormatRenderer struct {
+	formats map[string]Renderer
+}
+
+// NewMultiFormatRenderer creates a MultiFormatRenderer from the renderers
+// registered with WithFormatRenderer. Other options are ignored.
+func NewMultiFormatRenderer(opts ...Option) *MultiFormatRenderer {
+	c := newConfig(opts)
+	return &MultiFormatRenderer{formats: maps.Clone(c.formats)}
+}
+
+// Render renders the template name of the renderer registered for format.
+//
+// Returns ErrUnknownFormat if no renderer is registered for format, or the
+// error of the underlying renderer.
+func (m *MultiFormatRenderer) Render(ctx context.Context, w io.Writer, name string, format string, data any, funcs template.FuncMap) error {
+	r, ok := m.formats[format]
+	if !ok {
+		return ErrUnknownFormat
+	}
+	return RenderContext(ctx, r, w, name, data, funcs)
+}
+
+// FormatFromRequest returns the format preferred by the Accept header of req,
+// taking quality values into account. Media types other than HTML, plain text
+// and JSON are ignored.
+//
+// Returns FormatHTML if the header is missing or names none of these formats.
+func FormatFromRequest(req *http.Request) string {
+	format, best := FormatHTML, 0.0
+	for _, part := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		f, ok := formatMediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		if q > best {
+			format, best = f, q
+		}
+	}
+	return format
+}
@@ -0,0 +1,106 @@
+package tplx
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"maps"
+	"reflect"
+	"time"
+)
+
+// ErrFuncTimeout is returned by a template function limited with
+// WithFuncTimeout that did not return in time.
+var ErrFuncTimeout = errors.New("template function timed out")
+
+// WithFuncTimeout limits the duration of each call of the template function
+// name, whether it is global, registered for a fragment or passed to Render.
+// The function runs in its own goroutine, and if it does not return within d,
+// the call fails with ErrFuncTimeout, which aborts the render. The goroutine
+// keeps running until the function returns, so functions that can block for a
+// long time should still honor a context, see WithContextInjection.
+//
+// The function must return an error as its last value; otherwise NewRenderer
+// and renders passing such a function fail.
+func WithFuncTimeout(name string, d time.Duration) Option {
+	return func(c *config) {
+		if c.funcTimeouts == nil {
+			c.funcTimeouts = make(map[string]time.Duration)
+		}
+		c.funcTimeouts[name] = d
+	}
+}
+
+// limitFuncs returns funcs with the functions configured by WithFuncTimeout
+// wrapped, or funcs itself if none of them is part of it.
+func (c *config) limitFuncs(funcs template.FuncMap) (template.FuncMap, error) {
+	var m template.FuncMap
+	for name, d := range c.funcTimeouts {
+		fn, ok := funcs[name]
+		if !ok {
+			continue
+		}
+
+		limited, err := limitFunc(name, fn, d)
+		if err != nil {
+			return nil, err
+		}
+
+		if m == nil {
+			m = maps.Clone(funcs)
+		}
+		m[name] = limited
+	}
+	if m == nil {
+		return funcs, nil
+	}
+	return m, nil
+}
+
+// limitFunc returns a function like fn that fails with ErrFuncTimeout if fn
+// does not return within d.
+func limitFunc(name string, fn any, d time.Duration) (any, error) {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.NumOut() == 0 || t.Out(t.NumOut()-1) != errorType {
+		return nil, fmt.Errorf("template function %q must return an error to be limited by a timeout", name)
+	}
+
+	return reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+		done := make(chan []reflect.Value, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					done <- failedCall(t, fmt.Errorf("template function %q panicked: %v", name, p))
+				}
+			}()
+
+			if t.IsVariadic() {
+				done <- v.CallSlice(args)
+			} else {
+				done <- v.Call(args)
+			}
+		}()
+
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case out := <-done:
+			return out
+		case <-timer.C:
+			return failedCall(t, fmt.Errorf("%w: %q after %s", ErrFuncTimeout, name, d))
+		}
+	}).Interface(), nil
+}
+
+// failedCall returns the results of a call of a function of type t that
+// failed with err.
+func failedCall(t reflect.Type, err error) []reflect.Value {
+	out := make([]reflect.Value, t.NumOut())
+	for i := range out {
+		out[i] = reflect.Zero(t.Out(i))
+	}
+	out[len(out)-1] = reflect.ValueOf(&err).Elem()
+	return out
+}
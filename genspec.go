@@ -0,0 +1,112 @@
+package tplx
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// GenerateSpec builds a Spec from the struct tags of the struct v points to,
+// as an alternative to writing Spec literals:
+//
+//	type Templates struct {
+//		Home  struct{} `tplx:"name=home,path=templates/home.html"`
+//		Admin struct {
+//			Layout struct{} `tplx:"name=layout,path=templates/layout.html"`
+//			Page   struct{} `tplx:"name=admin,path=templates/admin.html,stripcomments"`
+//		} `tplx:"name=admin"`
+//	}
+//
+// A field whose tag has a path is a top-level template consisting of a single
+// fragment. A struct field whose tag has no path is a group: a top-level
+// template whose fragments are the tagged fields of the struct. The tag keys
+// are name, which defaults to the field name, path, extends, which sets
+// Meta.Extends instead of a path, format, encoding and the flag
+// stripcomments. Fields without a tplx tag, or with the tag "-", are ignored.
+//
+// Returns an error wrapping ErrInvalidSpec if v is not a pointer to a struct,
+// a tag is malformed, or the resulting Spec does not pass ValidateSpec.
+func GenerateSpec(v any) (Spec, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %T is not a pointer to a struct", ErrInvalidSpec, v)
+	}
+
+	spec := make(Spec)
+	for _, f := range reflect.VisibleFields(rv.Elem().Type()) {
+		tag, ok := f.Tag.Lookup("tplx")
+		if !ok || tag == "-" || f.Anonymous {
+			continue
+		}
+
+		meta, err := parseSpecTag(f, tag)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := spec[meta.Name]; ok {
+			return nil, fmt.Errorf("%w: template %q is defined more than once", ErrInvalidSpec, meta.Name)
+		}
+
+		if meta.Path != "" || meta.Extends != "" {
+			spec[meta.Name] = []Meta{meta}
+			continue
+		}
+
+		if f.Type.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%w: field %s has neither a path nor fragments", ErrInvalidSpec, f.Name)
+		}
+
+		var metas []Meta
+		for _, ff := range reflect.VisibleFields(f.Type) {
+			tag, ok := ff.Tag.Lookup("tplx")
+			if !ok || tag == "-" || ff.Anonymous {
+				continue
+			}
+
+			fragment, err := parseSpecTag(ff, tag)
+			if err != nil {
+				return nil, err
+			}
+			if fragment.Path == "" && fragment.Extends == "" {
+				return nil, fmt.Errorf("%w: fragment field %s.%s has no path", ErrInvalidSpec, f.Name, ff.Name)
+			}
+			metas = append(metas, fragment)
+		}
+		spec[meta.Name] = metas
+	}
+
+	if err := ValidateSpec(spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// parseSpecTag parses the tplx tag of the field f.
+func parseSpecTag(f reflect.StructField, tag string) (Meta, error) {
+	meta := Meta{Name: f.Name}
+	for _, part := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "name":
+			meta.Name = value
+		case "path":
+			meta.Path = value
+		case "extends":
+			meta.Extends = value
+		case "format":
+			meta.Format = value
+		case "encoding":
+			meta.Encoding = value
+		case "stripcomments":
+			meta.StripComments = true
+		default:
+			return Meta{}, fmt.Errorf("%w: unknown key %q in tag of field %s", ErrInvalidSpec, key, f.Name)
+		}
+	}
+
+	if meta.Name == "" {
+		return Meta{}, fmt.Errorf("%w: empty name in tag of field %s", ErrInvalidSpec, f.Name)
+	}
+	return meta, nil
+}
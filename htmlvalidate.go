@@ -0,0 +1,153 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// HTMLValidationError describes a single well-formedness problem in rendered
+// HTML. Line is the 1-based line of the output on which it was found.
+type HTMLValidationError struct {
+	Line    int
+	Message string
+}
+
+func (e HTMLValidationError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// ValidationError is returned by a render when strict HTML validation fails.
+// It carries all problems found in the output of the named template.
+type ValidationError struct {
+	Template string
+	Errors   []HTMLValidationError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("invalid HTML in template %q: %s", e.Template, strings.Join(msgs, "; "))
+}
+
+// WithHTMLValidation checks that rendered output is well-formed HTML, i.e.
+// that every element is closed in the right order. Elements whose end tag may
+// be omitted, such as <p> and <li>, and void elements are taken into account.
+//
+// In strict mode a render producing malformed HTML fails with a
+// *ValidationError and nothing is written. Otherwise the problems are logged
+// as warnings and the output is written unchanged. Validation only runs when
+// the renderer is in debug mode (see WithDebug).
+func WithHTMLValidation(strict bool) Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, func(next RenderHandler) RenderHandler {
+			return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+				if !c.debug {
+					return next(ctx, w, name, data, funcs)
+				}
+
+				var buf bytes.Buffer
+				if err := next(ctx, &buf, name, data, funcs); err != nil {
+					return err
+				}
+
+				if errs := validateHTML(buf.Bytes()); len(errs) > 0 {
+					verr := &ValidationError{Template: name, Errors: errs}
+					if strict {
+						return verr
+					}
+					c.log().WarnContext(ctx, "rendered invalid HTML", "template", name, "error", verr)
+				}
+
+				_, err := buf.WriteTo(w)
+				return err
+			}
+		})
+	}
+}
+
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
+
+var optionalEndElements = map[string]bool{
+	"html": true, "head": true, "body": true, "p": true, "li": true,
+	"dt": true, "dd": true, "option": true, "optgroup": true, "tr": true,
+	"td": true, "th": true, "thead": true, "tbody": true, "tfoot": true,
+	"colgroup": true, "caption": true, "rt": true, "rp": true,
+}
+
+// validateHTML returns all well-formedness problems found in b.
+func validateHTML(b []byte) []HTMLValidationError {
+	type open struct {
+		tag  string
+		line int
+	}
+
+	var (
+		errs  []HTMLValidationError
+		stack []open
+		line  = 1
+	)
+
+	z := html.NewTokenizer(bytes.NewReader(b))
+	for {
+		tt := z.Next()
+		start := line
+		line += bytes.Count(z.Raw(), []byte("\n"))
+
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); !errors.Is(err, io.EOF) {
+				errs = append(errs, HTMLValidationError{Line: start, Message: err.Error()})
+			}
+			for _, o := range stack {
+				if !optionalEndElements[o.tag] {
+					errs = append(errs, HTMLValidationError{Line: o.line, Message: fmt.Sprintf("unclosed <%s>", o.tag)})
+				}
+			}
+			return errs
+
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			if !voidElements[tag] {
+				stack = append(stack, open{tag: tag, line: start})
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			if voidElements[tag] {
+				errs = append(errs, HTMLValidationError{Line: start, Message: fmt.Sprintf("end tag for void element <%s>", tag)})
+				continue
+			}
+
+			i := len(stack) - 1
+			for i >= 0 && stack[i].tag != tag {
+				i--
+			}
+			if i < 0 {
+				errs = append(errs, HTMLValidationError{Line: start, Message: fmt.Sprintf("unexpected </%s>", tag)})
+				continue
+			}
+
+			for _, o := range stack[i+1:] {
+				if !optionalEndElements[o.tag] {
+					errs = append(errs, HTMLValidationError{Line: o.line, Message: fmt.Sprintf("unclosed <%s> before </%s>", o.tag, tag)})
+				}
+			}
+			stack = stack[:i]
+		}
+	}
+}
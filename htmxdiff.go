@@ -0,0 +1,319 @@
+package tplx
+
+import (
+	"bytes"
+	"container/list"
+	"html/template"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// HTMXDiffRenderer renders full pages for HTMX requests but only sends the
+// elements that changed since the previous render for the same session, as
+// out-of-band swaps.
+type HTMXDiffRenderer struct {
+	r       Renderer
+	session func(req *http.Request) string
+	c       htmxDiffConfig
+
+	mu   sync.Mutex
+	prev map[htmxDiffKey]*list.Element
+	lru  *list.List // of *htmxDiffEntry, most recently used first
+}
+
+type htmxDiffKey struct {
+	session string
+	name    string
+}
+
+type htmxDiffEntry struct {
+	key     htmxDiffKey
+	out     []byte
+	expires time.Time
+}
+
+// DefaultHTMXDiffEntries is the number of previous renders an
+// HTMXDiffRenderer keeps by default.
+const DefaultHTMXDiffEntries = 10000
+
+// HTMXDiffOption configures an HTMXDiffRenderer.
+type HTMXDiffOption func(*htmxDiffConfig)
+
+type htmxDiffConfig struct {
+	entries int
+	ttl     time.Duration
+}
+
+// WithHTMXDiffEntries sets the number of previous renders kept, one per
+// session and template. When it is exceeded, the least recently used render
+// is dropped, and the next request of its session is answered with the full
+// page. A limit of 0 or less selects DefaultHTMXDiffEntries.
+func WithHTMXDiffEntries(n int) HTMXDiffOption {
+	return func(c *htmxDiffConfig) {
+		c.entries = n
+	}
+}
+
+// WithHTMXDiffTTL sets the time for which a previous render is kept after it
+// was stored. A ttl of 0 or less keeps renders until they are evicted by the
+// limit of WithHTMXDiffEntries or dropped by Forget.
+func WithHTMXDiffTTL(ttl time.Duration) HTMXDiffOption {
+	return func(c *htmxDiffConfig) {
+		c.ttl = ttl
+	}
+}
+
+// NewHTMXDiffRenderer creates an HTMXDiffRenderer rendering with r. The
+// session function identifies the client of a request, e.g. by a session
+// cookie; requests for which it returns the empty string are always answered
+// with the full page.
+func NewHTMXDiffRenderer(r Renderer, session func(req *http.Request) string, opts ...HTMXDiffOption) *HTMXDiffRenderer {
+	var c htmxDiffConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.entries <= 0 {
+		c.entries = DefaultHTMXDiffEntries
+	}
+	return &HTMXDiffRenderer{
+		r:       r,
+		session: session,
+		c:       c,
+		prev:    make(map[htmxDiffKey]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// DiffRenderHTMX renders the template name and writes it to w.
+//
+// If req is an HTMX request, as signaled by the HX-Request header, and the
+// same template was rendered for the session before, both versions are parsed
+// and compared by their elements with an id attribute. Only the outermost
+// elements whose content changed are written, each marked with
+// hx-swap-oob="true" unless it already has an hx-swap-oob attribute, so that
+// HTMX replaces the element with the same id, and the HX-Reswap header is set
+// to none so that the target of the request is left alone. If anything
+// outside of elements with an id changed, the full page is written. The
+// latest output is kept per session and template until Forget is called, or
+// until it is evicted as configured by WithHTMXDiffEntries and
+// WithHTMXDiffTTL.
+//
+// Returns an error if the template cannot be rendered or the output cannot be
+// written.
+func (d *HTMXDiffRenderer) DiffRenderHTMX(w http.ResponseWriter, req *http.Request, name string, data any, funcs template.FuncMap) error {
+	var buf bytes.Buffer
+	if err := RenderContext(NewResponseContext(NewRequestContext(req.Context(), req), w), d.r, &buf, name, data, funcs); err != nil {
+		return err
+	}
+	out := buf.Bytes()
+
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+
+	session := d.session(req)
+	if session == "" {
+		_, err := w.Write(out)
+		return err
+	}
+
+	prev, ok := d.swap(htmxDiffKey{session: session, name: name}, slices.Clone(out))
+
+	if !ok || req.Header.Get("HX-Request") != "true" {
+		_, err := w.Write(out)
+		return err
+	}
+
+	swaps, ok := diffHTML(prev, out)
+	if !ok {
+		_, err := w.Write(out)
+		return err
+	}
+
+	w.Header().Set("HX-Reswap", "none")
+	for _, n := range swaps {
+		if !slices.ContainsFunc(n.Attr, func(a html.Attribute) bool { return a.Namespace == "" && a.Key == "hx-swap-oob" }) {
+			n.Attr = append(n.Attr, html.Attribute{Key: "hx-swap-oob", Val: "true"})
+		}
+		if err := html.Render(w, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// swap stores out as the latest render for key and returns the previous one,
+// if it has been kept and has not expired.
+func (d *HTMXDiffRenderer) swap(key htmxDiffKey, out []byte) ([]byte, bool) {
+	now := time.Now()
+	var expires time.Time
+	if d.c.ttl > 0 {
+		expires = now.Add(d.c.ttl)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if el, ok := d.prev[key]; ok {
+		e := el.Value.(*htmxDiffEntry)
+		prev, live := e.out, e.expires.IsZero() || now.Before(e.expires)
+		e.out, e.expires = out, expires
+		d.lru.MoveToFront(el)
+		return prev, live
+	}
+
+	d.prev[key] = d.lru.PushFront(&htmxDiffEntry{key: key, out: out, expires: expires})
+	for d.lru.Len() > d.c.entries {
+		d.remove(d.lru.Back())
+	}
+	return nil, false
+}
+
+// remove drops the entry el. The caller must hold d.mu.
+func (d *HTMXDiffRenderer) remove(el *list.Element) {
+	delete(d.prev, el.Value.(*htmxDiffEntry).key)
+	d.lru.Remove(el)
+}
+
+// Forget drops the previous renders of the session, e.g. when it ends.
+func (d *HTMXDiffRenderer) Forget(session string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, el := range d.prev {
+		if key.session == session {
+			d.remove(el)
+		}
+	}
+}
+
+// diffHTML compares two versions of a document and returns the outermost
+// elements of cur with an id attribute whose content differs from the element
+// with the same id in prev.
+//
+// Returns false if the documents cannot be parsed or differ outside of
+// elements with an id.
+func diffHTML(prev, cur []byte) ([]*html.Node, bool) {
+	prevDoc, err := html.Parse(bytes.NewReader(prev))
+	if err != nil {
+		return nil, false
+	}
+	curDoc, err := html.Parse(bytes.NewReader(cur))
+	if err != nil {
+		return nil, false
+	}
+
+	if !sameShallow(prevDoc, curDoc) {
+		return nil, false
+	}
+
+	prevByID := make(map[string]*html.Node)
+	for n := range walkHTML(prevDoc) {
+		if id := nodeID(n); id != "" {
+			if _, ok := prevByID[id]; !ok {
+				prevByID[id] = n
+			}
+		}
+	}
+
+	var swaps []*html.Node
+	var diff func(n *html.Node) bool
+	diff = func(n *html.Node) bool {
+		for _, c := range idDescendants(n) {
+			p, ok := prevByID[nodeID(c)]
+			if !ok {
+				return false
+			}
+			if !sameShallow(p, c) {
+				swaps = append(swaps, c)
+				continue
+			}
+			if !diff(c) {
+				return false
+			}
+		}
+		return true
+	}
+	if !diff(curDoc) {
+		return nil, false
+	}
+	return swaps, true
+}
+
+// sameShallow reports whether a and b render the same when elements with an
+// id below them are replaced by placeholders naming the id.
+func sameShallow(a, b *html.Node) bool {
+	var ab, bb bytes.Buffer
+	if html.Render(&ab, shallowClone(a)) != nil || html.Render(&bb, shallowClone(b)) != nil {
+		return false
+	}
+	return bytes.Equal(ab.Bytes(), bb.Bytes())
+}
+
+// shallowClone copies n and its descendants, replacing descendant elements
+// with an id by comments holding the id.
+func shallowClone(n *html.Node) *html.Node {
+	c := &html.Node{
+		Type:      n.Type,
+		DataAtom:  n.DataAtom,
+		Data:      n.Data,
+		Namespace: n.Namespace,
+		Attr:      slices.Clone(n.Attr),
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if id := nodeID(child); id != "" {
+			c.AppendChild(&html.Node{Type: html.CommentNode, Data: "id=" + id})
+			continue
+		}
+		c.AppendChild(shallowClone(child))
+	}
+	return c
+}
+
+// idDescendants returns the elements with an id below n that have no ancestor
+// with an id below n.
+func idDescendants(n *html.Node) []*html.Node {
+	var nodes []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if nodeID(c) != "" {
+			nodes = append(nodes, c)
+			continue
+		}
+		nodes = append(nodes, idDescendants(c)...)
+	}
+	return nodes
+}
+
+func nodeID(n *html.Node) string {
+	if n.Type != html.ElementNode {
+		return ""
+	}
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == "id" {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// walkHTML yields n and all of its descendants in document order.
+func walkHTML(n *html.Node) func(yield func(*html.Node) bool) {
+	return func(yield func(*html.Node) bool) {
+		var walk func(n *html.Node) bool
+		walk = func(n *html.Node) bool {
+			if !yield(n) {
+				return false
+			}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if !walk(c) {
+					return false
+				}
+			}
+			return true
+		}
+		walk(n)
+	}
+}
@@ -0,0 +1,163 @@
+package tplx
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type htmxPage struct {
+	A, B, Outside string
+	OOB           bool
+}
+
+func newHTMXDiffRenderer(tb testing.TB, opts ...HTMXDiffOption) *HTMXDiffRenderer {
+	tb.Helper()
+	fsys := fstest.MapFS{"page.html": {Data: []byte(
+		`<div id="a">{{.A}}</div><div id="b"{{if .OOB}} hx-swap-oob="outerHTML"{{end}}>{{.B}}</div><p>{{.Outside}}</p>`,
+	)}}
+	r, err := NewRenderer(fsys, Spec{"page": {{Name: "page", Path: "page.html"}}}, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	session := func(req *http.Request) string { return req.Header.Get("X-Session") }
+	return NewHTMXDiffRenderer(r, session, opts...)
+}
+
+func diffRender(tb testing.TB, d *HTMXDiffRenderer, session string, data htmxPage) *httptest.ResponseRecorder {
+	tb.Helper()
+	rec, err := tryDiffRender(d, session, data)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return rec
+}
+
+func tryDiffRender(d *HTMXDiffRenderer, session string, data htmxPage) (*httptest.ResponseRecorder, error) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("HX-Request", "true")
+	req.Header.Set("X-Session", session)
+	rec := httptest.NewRecorder()
+	return rec, d.DiffRenderHTMX(rec, req, "page", data, nil)
+}
+
+func TestDiffRenderHTMX(t *testing.T) {
+	const full = `<div id="a">1</div><div id="b">1</div><p>x</p>`
+
+	tests := []struct {
+		name       string
+		next       htmxPage
+		want       string
+		wantReswap string
+	}{
+		{name: "unchanged", next: htmxPage{A: "1", B: "1", Outside: "x"}, want: "", wantReswap: "none"},
+		{
+			name:       "changed element",
+			next:       htmxPage{A: "2", B: "1", Outside: "x"},
+			want:       `<div id="a" hx-swap-oob="true">2</div>`,
+			wantReswap: "none",
+		},
+		{
+			name:       "existing hx-swap-oob",
+			next:       htmxPage{A: "1", B: "2", Outside: "x", OOB: true},
+			want:       `<div id="b" hx-swap-oob="outerHTML">2</div>`,
+			wantReswap: "none",
+		},
+		{
+			name: "changed outside elements with id",
+			next: htmxPage{A: "1", B: "1", Outside: "y"},
+			want: `<div id="a">1</div><div id="b">1</div><p>y</p>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newHTMXDiffRenderer(t)
+			if got := diffRender(t, d, "s", htmxPage{A: "1", B: "1", Outside: "x"}).Body.String(); got != full {
+				t.Fatalf("first render: got %q, want %q", got, full)
+			}
+
+			rec := diffRender(t, d, "s", tt.next)
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if got := rec.Header().Get("HX-Reswap"); got != tt.wantReswap {
+				t.Errorf("HX-Reswap: got %q, want %q", got, tt.wantReswap)
+			}
+		})
+	}
+}
+
+func TestDiffRenderHTMXEviction(t *testing.T) {
+	first := htmxPage{A: "1", B: "1", Outside: "x"}
+	next := htmxPage{A: "2", B: "1", Outside: "x"}
+	const full = `<div id="a">2</div><div id="b">1</div><p>x</p>`
+
+	t.Run("entries", func(t *testing.T) {
+		d := newHTMXDiffRenderer(t, WithHTMXDiffEntries(1))
+		diffRender(t, d, "a", first)
+		diffRender(t, d, "b", first)
+		if got := diffRender(t, d, "a", next).Body.String(); got != full {
+			t.Errorf("evicted session: got %q, want the full page", got)
+		}
+		if got := len(d.prev); got != 1 {
+			t.Errorf("kept %d renders, want 1", got)
+		}
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		d := newHTMXDiffRenderer(t, WithHTMXDiffTTL(time.Nanosecond))
+		diffRender(t, d, "a", first)
+		time.Sleep(time.Millisecond)
+		if got := diffRender(t, d, "a", next).Body.String(); got != full {
+			t.Errorf("expired render: got %q, want the full page", got)
+		}
+	})
+
+	t.Run("forget", func(t *testing.T) {
+		d := newHTMXDiffRenderer(t)
+		diffRender(t, d, "a", first)
+		d.Forget("a")
+		if got := diffRender(t, d, "a", next).Body.String(); got != full {
+			t.Errorf("forgotten session: got %q, want the full page", got)
+		}
+	})
+}
+
+// TestDiffRenderHTMXConcurrentSessions renders for many sessions at once, for
+// the race detector, and checks that sessions do not see each other's
+// renders.
+func TestDiffRenderHTMXConcurrentSessions(t *testing.T) {
+	d := newHTMXDiffRenderer(t, WithHTMXDiffEntries(4))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session := fmt.Sprint(i)
+			for j := range 50 {
+				rec, err := tryDiffRender(d, session, htmxPage{A: fmt.Sprint(j), B: session, Outside: "x"})
+				if err != nil {
+					errs <- err
+					return
+				}
+				full := fmt.Sprintf(`<div id="a">%d</div><div id="b">%s</div><p>x</p>`, j, session)
+				diff := fmt.Sprintf(`<div id="a" hx-swap-oob="true">%d</div>`, j)
+				if got := rec.Body.String(); got != full && (j == 0 || got != diff) {
+					errs <- fmt.Errorf("session %s, render %d: got %q", session, j, got)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,78 @@
+package tplx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"maps"
+	"strings"
+)
+
+// MaxIncludeDepth is the number of nested renderTemplate calls allowed by
+// WithCrossTemplateIncludes.
+const MaxIncludeDepth = 10
+
+// ErrIncludeDepthExceeded is returned by renderTemplate when top-level
+// templates include each other more than MaxIncludeDepth levels deep, which
+// usually means that they include each other in a cycle.
+var ErrIncludeDepthExceeded = errors.New("template include depth exceeded")
+
+// WithCrossTemplateIncludes adds the template function renderTemplate, which
+// renders another top-level template of the same renderer with the given data
+// and returns its output, so that templates can include each other even though
+// their sub-templates are isolated:
+//
+//	{{renderTemplate "sidebar" .Sidebar}}
+//
+// The included template is rendered with the context of the including render
+// but without passing through its middlewares, output limit, timeout or
+// buffering again: its output becomes part of the output of the including
+// render, to which they apply as a whole, so that e.g. WithJSONLD does not
+// add its script once per include. The functions passed to the including
+// render, and the restrictions of a SandboxedRenderer, apply to the included
+// template as well. Locale selection and hot reloading apply
+// to the included template as well. Includes may be nested up to
+// MaxIncludeDepth levels; deeper includes fail with ErrIncludeDepthExceeded.
+// A renderTemplate function passed to Render takes precedence.
+func WithCrossTemplateIncludes() Option {
+	return func(c *config) {
+		c.crossIncludes = true
+		c.addFuncs(template.FuncMap{
+			// Replaced by a function bound to the render in includeFuncs.
+			"renderTemplate": func(name string, data any) (template.HTML, error) {
+				return "", errors.New("renderTemplate called outside of a render")
+			},
+		})
+	}
+}
+
+type includeDepthKey struct{}
+
+// includeFuncs returns funcs with renderTemplate bound to the render with the
+// context ctx and the functions funcs, unless funcs defines renderTemplate
+// itself.
+func (r *renderer) includeFuncs(ctx context.Context, funcs template.FuncMap) template.FuncMap {
+	if _, ok := funcs["renderTemplate"]; ok {
+		return funcs
+	}
+
+	depth, _ := ctx.Value(includeDepthKey{}).(int)
+
+	m := make(template.FuncMap, len(funcs)+1)
+	maps.Copy(m, funcs)
+	m["renderTemplate"] = func(name string, data any) (template.HTML, error) {
+		if depth >= MaxIncludeDepth {
+			return "", fmt.Errorf("%w: %q at depth %d", ErrIncludeDepthExceeded, name, depth)
+		}
+
+		var sb strings.Builder
+		ctx := context.WithValue(ctx, includeDepthKey{}, depth+1)
+		ctx = context.WithValue(ctx, blockKey{}, "")
+		if err := r.execute(ctx, &sb, r.localize(ctx, name), data, funcs); err != nil {
+			return "", err
+		}
+		return template.HTML(sb.String()), nil
+	}
+	return m
+}
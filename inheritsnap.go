@@ -0,0 +1,140 @@
+package tplx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// ErrSnapshotMismatch is returned by SnapshotInheritance if templates no
+// longer match their snapshots.
+var ErrSnapshotMismatch = errors.New("template output does not match snapshot")
+
+// inheritanceSnapshot is the JSON snapshot of a single top-level template.
+type inheritanceSnapshot struct {
+	Template string            `json:"template"`
+	Output   string            `json:"output"`
+	Blocks   map[string]string `json:"blocks"`
+}
+
+// SnapshotInheritance compares every top-level template of r against its
+// snapshot in dir, to catch changes to a template that unexpectedly affect
+// the templates extending it.
+//
+// Each template and each of its blocks is rendered with nil data, and the
+// names and output of the blocks are compared with those recorded by
+// UpdateInheritanceSnapshots. Errors of renders that fail are recorded in
+// place of their output, so that templates requiring data can be snapshotted
+// too.
+//
+// Returns an error wrapping ErrSnapshotMismatch naming the templates whose
+// snapshots are missing or differ, an error wrapping errors.ErrUnsupported if
+// r was not created by this package, or an error if a snapshot cannot be
+// read.
+func SnapshotInheritance(r Renderer, dir string) error {
+	rr, ok := r.(*renderer)
+	if !ok {
+		return fmt.Errorf("cannot snapshot %T: %w", r, errors.ErrUnsupported)
+	}
+
+	var mismatched []string
+	for _, name := range rr.Names() {
+		want, err := os.ReadFile(snapshotPath(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			mismatched = append(mismatched, name)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read snapshot: %w", err)
+		}
+
+		got, err := rr.inheritanceSnapshot(name)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(got, want) {
+			mismatched = append(mismatched, name)
+		}
+	}
+
+	if len(mismatched) > 0 {
+		return fmt.Errorf("%w: %q", ErrSnapshotMismatch, mismatched)
+	}
+	return nil
+}
+
+// UpdateInheritanceSnapshots writes the snapshots of all top-level templates
+// of r to dir, creating it if necessary. See SnapshotInheritance.
+//
+// Returns an error wrapping errors.ErrUnsupported if r was not created by this
+// package, or an error if a snapshot cannot be written.
+func UpdateInheritanceSnapshots(r Renderer, dir string) error {
+	rr, ok := r.(*renderer)
+	if !ok {
+		return fmt.Errorf("cannot snapshot %T: %w", r, errors.ErrUnsupported)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for _, name := range rr.Names() {
+		b, err := rr.inheritanceSnapshot(name)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(snapshotPath(dir, name), b, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotPath returns the path of the snapshot of the top-level template
+// name in dir.
+func snapshotPath(dir, name string) string {
+	return filepath.Join(dir, url.PathEscape(name)+".json")
+}
+
+// inheritanceSnapshot renders the snapshot of the top-level template name.
+func (r *renderer) inheritanceSnapshot(name string) ([]byte, error) {
+	blocks, err := r.TemplateBlocks(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Blocks are executed directly, so that the snapshot only depends on the
+	// templates and not on the options of the renderer.
+	render := func(block string) string {
+		var buf bytes.Buffer
+		if err := r.executeBlock(&buf, name, block, nil, nil); err != nil {
+			return "error: " + err.Error()
+		}
+		return buf.String()
+	}
+
+	snap := inheritanceSnapshot{
+		Template: name,
+		Output:   render(name),
+		Blocks:   make(map[string]string, len(blocks)),
+	}
+	for _, block := range blocks {
+		snap.Blocks[block] = render(block)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(snap); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
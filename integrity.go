@@ -0,0 +1,75 @@
+package tplx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+)
+
+// ErrIntegrity is returned when a template file does not match its expected
+// hash.
+var ErrIntegrity = errors.New("template file integrity check failed")
+
+// HashSpec computes the SHA-256 hash of every template file in spec, e.g. at
+// build time, for later verification with VerifySpec or WithIntegrityCheck.
+//
+// Returns a map from each Meta path to its hex-encoded hash, or an error if a
+// file cannot be read.
+func HashSpec(fsys fs.FS, spec Spec) (map[string]string, error) {
+	hashes := make(map[string]string)
+	for _, path := range specPaths(spec) {
+		text, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read template file: %w", err)
+		}
+
+		sum := sha256.Sum256(text)
+		hashes[path] = hex.EncodeToString(sum[:])
+	}
+	return hashes, nil
+}
+
+// VerifySpec checks that the template files in spec still have the given
+// hashes, as computed by HashSpec.
+//
+// Returns an error wrapping ErrIntegrity naming the first path, in sorted
+// order, whose hash differs or is missing from hashes.
+func VerifySpec(fsys fs.FS, spec Spec, hashes map[string]string) error {
+	actual, err := HashSpec(fsys, spec)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range specPaths(spec) {
+		if expected, ok := hashes[path]; !ok || expected != actual[path] {
+			return fmt.Errorf("%w: %q", ErrIntegrity, path)
+		}
+	}
+	return nil
+}
+
+// WithIntegrityCheck verifies the template files against hashes with
+// VerifySpec before they are parsed, both when the renderer is created and when
+// templates are reloaded.
+func WithIntegrityCheck(hashes map[string]string) Option {
+	return func(c *config) {
+		c.integrity = hashes
+	}
+}
+
+// specPaths returns the sorted paths of all template files in spec.
+func specPaths(spec Spec) []string {
+	var paths []string
+	for _, metas := range spec {
+		for _, meta := range metas {
+			if meta.Extends == "" && !slices.Contains(paths, meta.Path) {
+				paths = append(paths, meta.Path)
+			}
+		}
+	}
+	slices.Sort(paths)
+	return paths
+}
@@ -0,0 +1,70 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+)
+
+// WithJSONLD injects structured data for search engines into rendered pages.
+// For each render, extractor is called with the template name and data; if it
+// returns a non-nil value, the value is encoded as JSON and inserted as a
+// <script type="application/ld+json"> element right before the first </head>
+// of the output, or appended if there is none. The encoding escapes <, > and
+// &, so the data cannot end the script element early.
+//
+// Pages are rendered into a buffer first, so that nothing is written if the
+// extractor or the encoding fails.
+func WithJSONLD(extractor func(name string, data any) (any, error)) Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, func(next RenderHandler) RenderHandler {
+			return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+				ld, err := extractor(name, data)
+				if err != nil {
+					return fmt.Errorf("cannot extract structured data: %w", err)
+				}
+				if ld == nil {
+					return next(ctx, w, name, data, funcs)
+				}
+
+				b, err := json.Marshal(ld)
+				if err != nil {
+					return fmt.Errorf("cannot encode structured data: %w", err)
+				}
+				script := []byte(`<script type="application/ld+json">` + string(b) + "</script>")
+
+				var buf bytes.Buffer
+				if err := next(ctx, &buf, name, data, funcs); err != nil {
+					return err
+				}
+
+				out := buf.Bytes()
+				i := indexFold(out, []byte("</head>"))
+				if i < 0 {
+					i = len(out)
+				}
+
+				for _, b := range [][]byte{out[:i], script, out[i:]} {
+					if _, err := w.Write(b); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+		})
+	}
+}
+
+// indexFold returns the index of the first instance of sub in b, ignoring
+// ASCII case, or -1 if sub is not present.
+func indexFold(b, sub []byte) int {
+	for i := 0; i+len(sub) <= len(b); i++ {
+		if bytes.EqualFold(b[i:i+len(sub)], sub) {
+			return i
+		}
+	}
+	return -1
+}
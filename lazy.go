@@ -0,0 +1,27 @@
+package tplx
+
+import (
+	"html/template"
+	"sync"
+)
+
+// LazyFuncMap returns template functions that compute expensive values only
+// if a template actually uses them, such as the result of a query that only
+// some branches of a template display.
+//
+// Each thunk becomes a function of the same name that runs the thunk on its
+// first call and returns the cached result, or error, on every later call. The
+// thunks are independent of each other, and each one is safe to call from
+// multiple goroutines.
+//
+// To cache values for a single render, create the map per render and pass it
+// as funcs to Render. As template functions must exist when templates are
+// parsed, the same names must also be registered as global functions, e.g.
+// with a LazyFuncMap of placeholder thunks.
+func LazyFuncMap(thunks map[string]func() (any, error)) template.FuncMap {
+	funcs := make(template.FuncMap, len(thunks))
+	for name, thunk := range thunks {
+		funcs[name] = sync.OnceValues(thunk)
+	}
+	return funcs
+}
@@ -0,0 +1,94 @@
+package tplx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+)
+
+// ErrOutputLimitExceeded is returned when a render produces more output than
+// allowed by WithOutputLimit. The actual error is an *OutputLimitError.
+var ErrOutputLimitExceeded = errors.New("template output limit exceeded")
+
+// OutputLimitError is returned by a LimitedWriter once its limit is exceeded.
+// Limit is the number of bytes allowed and Written the number of bytes that
+// were attempted to be written in total, including the rejected ones.
+type OutputLimitError struct {
+	Limit   int64
+	Written int64
+}
+
+func (e *OutputLimitError) Error() string {
+	return fmt.Sprintf("%v: attempted to write %d bytes, limit is %d", ErrOutputLimitExceeded, e.Written, e.Limit)
+}
+
+func (e *OutputLimitError) Is(target error) bool {
+	return target == ErrOutputLimitExceeded
+}
+
+// LimitedWriter writes to W until Limit bytes have been written. Any write
+// beyond the limit writes the bytes that still fit and fails with an
+// *OutputLimitError, which aborts template execution.
+type LimitedWriter struct {
+	W       io.Writer
+	Limit   int64
+	Written int64
+}
+
+// LimitWriter returns a LimitedWriter writing at most limit bytes to w.
+func LimitWriter(w io.Writer, limit int64) *LimitedWriter {
+	return &LimitedWriter{W: w, Limit: limit}
+}
+
+func (lw *LimitedWriter) Write(p []byte) (int, error) {
+	remaining := max(lw.Limit-lw.Written, 0)
+	if int64(len(p)) <= remaining {
+		n, err := lw.W.Write(p)
+		lw.Written += int64(n)
+		return n, err
+	}
+
+	n, err := lw.W.Write(p[:remaining])
+	attempted := lw.Written + int64(len(p))
+	lw.Written += int64(n)
+	if err != nil {
+		return n, err
+	}
+	return n, &OutputLimitError{Limit: lw.Limit, Written: attempted}
+}
+
+// WithOutputLimit aborts renders whose output exceeds limit bytes with
+// ErrOutputLimitExceeded, protecting against runaway templates, e.g. a range
+// over unexpectedly large data. Output up to the limit may already have been
+// written. A limit of 0 or less disables the check. The limit can be
+// overridden for a single render with NewOutputLimitContext.
+func WithOutputLimit(limit int64) Option {
+	return func(c *config) {
+		c.outputLimit = limit
+	}
+}
+
+type outputLimitKey struct{}
+
+// NewOutputLimitContext returns a copy of ctx that overrides the output limit
+// of renders using it. A limit of 0 or less disables the check for these
+// renders.
+func NewOutputLimitContext(ctx context.Context, limit int64) context.Context {
+	return context.WithValue(ctx, outputLimitKey{}, limit)
+}
+
+func limitOutput(next RenderHandler, limit int64) RenderHandler {
+	return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+		limit := limit
+		if l, ok := ctx.Value(outputLimitKey{}).(int64); ok {
+			limit = l
+		}
+
+		if limit <= 0 {
+			return next(ctx, w, name, data, funcs)
+		}
+		return next(ctx, LimitWriter(w, limit), name, data, funcs)
+	}
+}
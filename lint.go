@@ -0,0 +1,223 @@
+package tplx
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template/parse"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LintIssue describes a likely mistake found by LintSpec.
+//
+// Template is the top-level template and Fragment the fragment in which the
+// issue was found. Line is the 1-based line number within the fragment's file,
+// or 0 if the issue is not tied to a line.
+type LintIssue struct {
+	Template string
+	Fragment string
+	Line     int
+	Message  string
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s/%s:%d: %s", i.Template, i.Fragment, i.Line, i.Message)
+}
+
+// LintSpec checks the templates described by spec for common mistakes that the
+// parser either accepts or reports unclearly.
+//
+// The following issues are reported: field references starting with a
+// lowercase letter, which only work for map keys since unexported struct
+// fields cannot be accessed; {{range}} actions without an {{else}} branch; parse
+// errors such as a missing {{end}}; and {{template}} actions naming templates
+// that are not defined within the same top-level template.
+//
+// Returns the issues sorted by template, fragment and line.
+func LintSpec(fsys fs.FS, spec Spec) []LintIssue {
+	var issues []LintIssue
+
+	names := make([]string, 0, len(spec))
+	for name := range spec {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		metas, err := resolveExtends(spec, name)
+		if err != nil {
+			issues = append(issues, LintIssue{Template: name, Message: err.Error()})
+			continue
+		}
+
+		defined := make(map[string]bool)
+		type parsed struct {
+			meta  Meta
+			trees map[string]*parse.Tree
+		}
+		var fragments []parsed
+
+		for _, meta := range metas {
+			defined[meta.Name] = true
+
+			text, err := fs.ReadFile(fsys, meta.Path)
+			if err != nil {
+				issues = append(issues, LintIssue{Template: name, Fragment: meta.Name, Message: err.Error()})
+				continue
+			}
+
+			trees := make(map[string]*parse.Tree)
+			t := parse.New(meta.Name)
+			t.Mode = parse.SkipFuncCheck
+			if _, err := t.Parse(string(text), "", "", trees); err != nil {
+				issues = append(issues, parseIssue(name, meta.Name, err))
+				continue
+			}
+
+			for tn := range trees {
+				defined[tn] = true
+			}
+			fragments = append(fragments, parsed{meta: meta, trees: trees})
+		}
+
+		for _, f := range fragments {
+			for _, tree := range f.trees {
+				l := linter{tree: tree, template: name, fragment: f.meta.Name, defined: defined}
+				if tree.Root != nil {
+					l.walk(tree.Root)
+				}
+				issues = append(issues, l.issues...)
+			}
+		}
+	}
+
+	slices.SortStableFunc(issues, func(a, b LintIssue) int {
+		if c := strings.Compare(a.Template, b.Template); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Fragment, b.Fragment); c != 0 {
+			return c
+		}
+		return a.Line - b.Line
+	})
+
+	return issues
+}
+
+// parseIssue converts a parse error into a LintIssue, rephrasing the messages
+// for unterminated control structures.
+func parseIssue(name, fragment string, err error) LintIssue {
+	msg := err.Error()
+	line := 0
+
+	// Parse errors have the form "template: NAME:LINE: MESSAGE".
+	if rest, ok := strings.CutPrefix(msg, "template: "+fragment+":"); ok {
+		if n, m, ok := strings.Cut(rest, ": "); ok {
+			if l, err := strconv.Atoi(n); err == nil {
+				line = l
+				msg = m
+			}
+		}
+	}
+
+	if strings.Contains(msg, "unexpected EOF") {
+		msg = "missing {{end}}: " + msg
+	}
+
+	return LintIssue{Template: name, Fragment: fragment, Line: line, Message: msg}
+}
+
+type linter struct {
+	tree     *parse.Tree
+	template string
+	fragment string
+	defined  map[string]bool
+	issues   []LintIssue
+}
+
+func (l *linter) report(n parse.Node, format string, args ...any) {
+	line := 0
+	loc, _ := l.tree.ErrorContext(n)
+	// The location has the form "NAME:LINE:COL".
+	parts := strings.Split(loc, ":")
+	if len(parts) >= 3 {
+		line, _ = strconv.Atoi(parts[len(parts)-2])
+	}
+
+	l.issues = append(l.issues, LintIssue{
+		Template: l.template,
+		Fragment: l.fragment,
+		Line:     line,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) checkFields(n parse.Node, idents []string) {
+	for _, ident := range idents {
+		r, _ := utf8.DecodeRuneInString(ident)
+		if unicode.IsLower(r) {
+			l.report(n, "field %q is unexported and can only refer to a map key", ident)
+		}
+	}
+}
+
+func (l *linter) walk(n parse.Node) {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			l.walk(c)
+		}
+	case *parse.ActionNode:
+		l.walk(n.Pipe)
+	case *parse.IfNode:
+		l.walkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		l.walkBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		if n.ElseList == nil {
+			l.report(n, "{{range}} has no {{else}} branch for empty collections")
+		}
+		l.walkBranch(&n.BranchNode)
+	case *parse.TemplateNode:
+		if !l.defined[n.Name] {
+			l.report(n, "template %q is not defined in %q", n.Name, l.template)
+		}
+		if n.Pipe != nil {
+			l.walk(n.Pipe)
+		}
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			l.walk(c)
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			l.walk(a)
+		}
+	case *parse.FieldNode:
+		l.checkFields(n, n.Ident)
+	case *parse.ChainNode:
+		l.walk(n.Node)
+		l.checkFields(n, n.Field)
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 {
+			l.checkFields(n, n.Ident[1:])
+		}
+	}
+}
+
+func (l *linter) walkBranch(n *parse.BranchNode) {
+	l.walk(n.Pipe)
+	l.walk(n.List)
+	if n.ElseList != nil {
+		l.walk(n.ElseList)
+	}
+}
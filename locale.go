@@ -0,0 +1,72 @@
+package tplx
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"maps"
+)
+
+// WithLocaleSelector renders locale-specific variants of templates. For each
+// render, fn is called with the render context and, if it returns a non-empty
+// locale, the template name followed by an underscore and the locale is
+// rendered instead of name, e.g. home_fr instead of home. If no such template
+// exists, the unlocalized template is rendered.
+//
+// Since fn receives the full context, the locale can come from a session, the
+// Accept-Language header of the request (see RequestFromContext) or a user
+// preference.
+func WithLocaleSelector(fn func(ctx context.Context) string) Option {
+	return func(c *config) {
+		c.localeSelector = fn
+	}
+}
+
+// localize returns the name of the localized variant of the template name for
+// ctx, or name itself if there is none.
+func (r *renderer) localize(ctx context.Context, name string) string {
+	if r.c.localeSelector == nil {
+		return name
+	}
+
+	locale := r.c.localeSelector(ctx)
+	if locale == "" {
+		return name
+	}
+
+	if _, ok := r.lookup(name + "_" + locale); ok {
+		return name + "_" + locale
+	}
+	return name
+}
+
+// WithLocalizedFuncs adds template functions that depend on the locale, such
+// as number, currency and date formatting. For each render, fn is called with
+// the locale returned by the selector configured with WithLocaleSelector, or
+// the empty string if there is none, and the functions it returns are used
+// for that render. Functions passed to Render take precedence.
+//
+// Since templates are parsed before any locale is known, fn is also called
+// once with the empty string when the option is applied, and the functions it
+// returns are added to the global functions, so it must return the same set of
+// names for every locale.
+func WithLocalizedFuncs(fn func(locale string) template.FuncMap) Option {
+	return func(c *config) {
+		c.addFuncs(fn(""))
+		c.middlewares = append(c.middlewares, func(next RenderHandler) RenderHandler {
+			return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+				var locale string
+				if c.localeSelector != nil {
+					locale = c.localeSelector(ctx)
+				}
+
+				localized := maps.Clone(fn(locale))
+				if localized == nil {
+					localized = make(template.FuncMap, len(funcs))
+				}
+				maps.Copy(localized, funcs)
+				return next(ctx, w, name, data, localized)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package tplx
+
+import "log/slog"
+
+// WithLogger sets the logger used for warnings and diagnostics emitted by the
+// renderer. By default slog.Default is used.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithDebug enables development-only features such as HTML validation. These
+// features are skipped entirely when debug is false, so they can be left
+// configured in production.
+func WithDebug(debug bool) Option {
+	return func(c *config) {
+		c.debug = debug
+	}
+}
+
+func (c *config) log() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return slog.Default()
+}
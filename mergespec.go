@@ -0,0 +1,63 @@
+package tplx
+
+import (
+	"fmt"
+	"slices"
+)
+
+// MergeStrategy determines how MergeSpecsWithStrategy handles top-level
+// templates defined by more than one spec.
+type MergeStrategy int
+
+const (
+	// MergeStrategyError fails the merge.
+	MergeStrategyError MergeStrategy = iota
+
+	// MergeStrategyFirst keeps the fragments of the first spec defining the
+	// template.
+	MergeStrategyFirst
+
+	// MergeStrategyLast replaces the fragments with those of the last spec
+	// defining the template.
+	MergeStrategyLast
+
+	// MergeStrategyAppend concatenates the fragments of all specs defining
+	// the template in order, so that a spec can extend a template of another
+	// one by adding fragments.
+	MergeStrategyAppend
+)
+
+// MergeSpecsWithStrategy merges specs into a single Spec, resolving top-level
+// templates defined by several of them according to strategy. The fragment
+// slices of the result are copies, so modifying it does not affect specs.
+//
+// Returns the merged spec, or an error wrapping ErrInvalidSpec if strategy is
+// MergeStrategyError and a template is defined more than once, or if strategy
+// is unknown. The result is not validated; with MergeStrategyAppend in
+// particular, it may define a fragment twice, which ValidateSpec reports.
+func MergeSpecsWithStrategy(strategy MergeStrategy, specs ...Spec) (Spec, error) {
+	if strategy < MergeStrategyError || strategy > MergeStrategyAppend {
+		return nil, fmt.Errorf("%w: unknown merge strategy %d", ErrInvalidSpec, strategy)
+	}
+
+	merged := make(Spec)
+	for _, spec := range specs {
+		for name, metas := range spec {
+			existing, ok := merged[name]
+			if !ok {
+				merged[name] = slices.Clone(metas)
+				continue
+			}
+
+			switch strategy {
+			case MergeStrategyError:
+				return nil, fmt.Errorf("%w: template %q is defined by more than one spec", ErrInvalidSpec, name)
+			case MergeStrategyLast:
+				merged[name] = slices.Clone(metas)
+			case MergeStrategyAppend:
+				merged[name] = append(existing, metas...)
+			}
+		}
+	}
+	return merged, nil
+}
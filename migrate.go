@@ -0,0 +1,56 @@
+package tplx
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// MigrateSpec applies path renames to all Meta paths in spec, e.g. after the
+// template directory has been reorganized. It only transforms the spec and
+// does not access any files.
+//
+// The renames parameter maps old paths to new paths. Returns the migrated
+// spec, which shares no slices with spec, and the applied renames in the form
+// "old -> new" in sorted order.
+//
+// Returns an error if any old path in renames does not occur in spec, in
+// which case no spec is returned.
+func MigrateSpec(spec Spec, renames map[string]string) (Spec, []string, error) {
+	used := make(map[string]bool, len(renames))
+
+	migrated := make(Spec, len(spec))
+	for name, metas := range spec {
+		metas = slices.Clone(metas)
+		for i, meta := range metas {
+			if meta.Extends != "" {
+				continue
+			}
+
+			if newPath, ok := renames[meta.Path]; ok {
+				metas[i].Path = newPath
+				used[meta.Path] = true
+			}
+		}
+		migrated[name] = metas
+	}
+
+	var unknown []string
+	for _, oldPath := range slices.Sorted(maps.Keys(renames)) {
+		if !used[oldPath] {
+			unknown = append(unknown, fmt.Sprintf("%q", oldPath))
+		}
+	}
+
+	if len(unknown) > 0 {
+		return nil, nil, fmt.Errorf("paths not found in spec: %s", strings.Join(unknown, ", "))
+	}
+
+	applied := make([]string, 0, len(renames))
+	for _, oldPath := range slices.Sorted(maps.Keys(renames)) {
+		applied = append(applied, oldPath+" -> "+renames[oldPath])
+	}
+
+	return migrated, applied, nil
+}
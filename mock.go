@@ -0,0 +1,154 @@
+package tplx
+
+import (
+	"fmt"
+	"html/template"
+	"maps"
+	"reflect"
+)
+
+// MockCall is a canned response of a mocked template function for
+// MockFuncs. A call whose arguments equal Args, as reported by
+// reflect.DeepEqual, returns Returns. A nil Args matches any arguments.
+type MockCall struct {
+	Args    []any
+	Returns []any
+}
+
+// MockFuncs returns a copy of original in which the functions named by the
+// keys of mocks are replaced, e.g. to render templates calling databases or
+// APIs in unit tests.
+//
+// A mock is either a function of the same type as the original, a MockCall,
+// or a []MockCall whose calls are tried in order. When a call matches no
+// MockCall, the mock fails with an error if the function returns one, and
+// panics otherwise, which template execution reports as an error.
+//
+// Returns the mocked functions, or an error if original has no function of a
+// mocked name, a mock function has a different type than the original, or the
+// returns of a MockCall do not fit the results of the original.
+func MockFuncs(original template.FuncMap, mocks map[string]any) (template.FuncMap, error) {
+	funcs := maps.Clone(original)
+	if funcs == nil {
+		funcs = make(template.FuncMap, len(mocks))
+	}
+
+	for name, mock := range mocks {
+		fn, ok := original[name]
+		if !ok {
+			return nil, fmt.Errorf("no template function %q to mock", name)
+		}
+
+		t := reflect.TypeOf(fn)
+		var calls []MockCall
+		switch m := mock.(type) {
+		case MockCall:
+			calls = []MockCall{m}
+		case []MockCall:
+			calls = m
+		default:
+			if mt := reflect.TypeOf(mock); mt != t {
+				return nil, fmt.Errorf("mock of template function %q has type %v, want %v", name, mt, t)
+			}
+			funcs[name] = mock
+			continue
+		}
+
+		f, err := mockFunc(name, t, calls)
+		if err != nil {
+			return nil, err
+		}
+		funcs[name] = f
+	}
+	return funcs, nil
+}
+
+// mockFunc returns a function of type t answering calls with the first
+// matching MockCall.
+func mockFunc(name string, t reflect.Type, calls []MockCall) (any, error) {
+	results := make([][]reflect.Value, len(calls))
+	for i, call := range calls {
+		if len(call.Returns) != t.NumOut() {
+			return nil, fmt.Errorf("mock call %d of template function %q returns %d values, want %d", i, name, len(call.Returns), t.NumOut())
+		}
+
+		results[i] = make([]reflect.Value, t.NumOut())
+		for j, ret := range call.Returns {
+			out := t.Out(j)
+			if ret == nil {
+				switch out.Kind() {
+				case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice:
+					results[i][j] = reflect.Zero(out)
+					continue
+				}
+				return nil, fmt.Errorf("mock call %d of template function %q returns nil for %v", i, name, out)
+			}
+
+			v := reflect.ValueOf(ret)
+			if !v.Type().AssignableTo(out) {
+				return nil, fmt.Errorf("mock call %d of template function %q returns %v for %v", i, name, v.Type(), out)
+			}
+			results[i][j] = v
+		}
+	}
+
+	return reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+		argv := make([]any, len(args))
+		for i, arg := range args {
+			argv[i] = arg.Interface()
+		}
+		if t.IsVariadic() {
+			last := args[len(args)-1]
+			argv = argv[:len(argv)-1]
+			for i := range last.Len() {
+				argv = append(argv, last.Index(i).Interface())
+			}
+		}
+
+		for i, call := range calls {
+			if call.Args == nil || reflect.DeepEqual(call.Args, argv) {
+				return results[i]
+			}
+		}
+
+		err := fmt.Errorf("no mock call of template function %q matches arguments %v", name, argv)
+		if t.NumOut() > 0 && t.Out(t.NumOut()-1) == errorType {
+			return failedCall(t, err)
+		}
+		panic(err)
+	}).Interface(), nil
+}
+
+// WithMockFuncs replaces template functions with mocks as described by
+// MockFuncs, whether they are global, registered for a fragment or passed to
+// Render. Mocks for functions that are not defined are ignored. It is meant
+// for unit tests of templates calling external services.
+//
+// NewRenderer, and renders passing mocked functions, fail if a mock does not
+// fit the function it replaces.
+func WithMockFuncs(mocks map[string]any) Option {
+	return func(c *config) {
+		if c.mocks == nil {
+			c.mocks = make(map[string]any, len(mocks))
+		}
+		maps.Copy(c.mocks, mocks)
+	}
+}
+
+// mockFuncs applies the mocks configured by WithMockFuncs to funcs.
+func (c *config) mockFuncs(funcs template.FuncMap) (template.FuncMap, error) {
+	var mocks map[string]any
+	for name, mock := range c.mocks {
+		if _, ok := funcs[name]; !ok {
+			continue
+		}
+		if mocks == nil {
+			mocks = make(map[string]any)
+		}
+		mocks[name] = mock
+	}
+	if mocks == nil {
+		return funcs, nil
+	}
+	return MockFuncs(funcs, mocks)
+}
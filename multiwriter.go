@@ -0,0 +1,59 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"io"
+	"slices"
+)
+
+// RenderMultiWriter renders the named template using r once, writing the
+// output to all of writers simultaneously, e.g. to a response, an audit log
+// and a cache entry.
+//
+// The output is written through io.MultiWriter, so each chunk goes to the
+// writers in order. If any writer returns an error, the render aborts with
+// that error, the remaining writers do not receive the chunk, and every
+// writer may be left with partial output. If the render itself fails, all
+// writers have received the output produced up to the failure. Use a
+// StrictMultiWriter to write only complete output.
+func RenderMultiWriter(ctx context.Context, r Renderer, writers []io.Writer, name string, data any, funcs template.FuncMap) error {
+	return RenderContext(ctx, r, io.MultiWriter(writers...), name, data, funcs)
+}
+
+// StrictMultiWriter buffers everything written to it and passes it on to
+// multiple writers only when Flush is called. Rendering into a
+// StrictMultiWriter and calling Flush only on success ensures that no writer
+// receives partial output of a failed render.
+type StrictMultiWriter struct {
+	writers []io.Writer
+	buf     bytes.Buffer
+}
+
+// NewStrictMultiWriter creates a StrictMultiWriter for writers.
+func NewStrictMultiWriter(writers ...io.Writer) *StrictMultiWriter {
+	return &StrictMultiWriter{writers: slices.Clone(writers)}
+}
+
+// Write appends p to the buffer. It never fails.
+func (sw *StrictMultiWriter) Write(p []byte) (int, error) {
+	return sw.buf.Write(p)
+}
+
+// Flush writes the buffered output to every writer and resets the buffer. A
+// failing writer does not prevent the output from being written to the
+// others.
+//
+// Returns the errors of all failing writers joined together.
+func (sw *StrictMultiWriter) Flush() error {
+	var errs []error
+	for _, w := range sw.writers {
+		if _, err := w.Write(sw.buf.Bytes()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	sw.buf.Reset()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,97 @@
+package tplx
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// NormalizeSpec returns a canonical copy of spec, so that generated specs
+// describing the same templates compare and parse identically. It is meant to
+// be applied before passing such a spec to NewRenderer.
+//
+// Within each top-level template, fragments are sorted by name and format,
+// and later fragments with the same name, format and path as an earlier one
+// are removed, as are repeated Extends entries. Extends entries are sorted
+// before all fragments but keep their relative order, since it determines the
+// inheritance order. Fields that have no effect are reset to their zero
+// values: empty Funcs and Annotations maps become nil, a Format of "html"
+// becomes "", and all fields other than Extends are cleared on Extends
+// entries.
+//
+// Since a fragment parsed later replaces blocks of the same name defined by
+// earlier fragments, sorting changes the result for templates relying on the
+// order of their fragments to override a {{block}}. Such templates should
+// inherit the fragment containing the block through Extends instead.
+//
+// Returns the normalized spec, or an error wrapping ErrInvalidSpec if a
+// fragment name occurs more than once within the same top-level template and
+// format with different paths.
+func NormalizeSpec(spec Spec) (Spec, error) {
+	type key struct {
+		format string
+		name   string
+	}
+
+	out := make(Spec, len(spec))
+	for _, name := range slices.Sorted(maps.Keys(spec)) {
+		metas := make([]Meta, 0, len(spec[name]))
+		seen := make(map[key]string, len(spec[name]))
+		extends := make(map[string]bool)
+
+		for _, meta := range spec[name] {
+			meta = normalizeMeta(meta)
+
+			if meta.Extends != "" {
+				if !extends[meta.Extends] {
+					extends[meta.Extends] = true
+					metas = append(metas, meta)
+				}
+				continue
+			}
+
+			k := key{format: meta.Format, name: meta.Name}
+			if path, ok := seen[k]; ok {
+				if path != meta.Path {
+					return nil, fmt.Errorf("%w: fragment %q of template %q is defined by both %q and %q", ErrInvalidSpec, meta.Name, name, path, meta.Path)
+				}
+				continue
+			}
+			seen[k] = meta.Path
+			metas = append(metas, meta)
+		}
+
+		slices.SortStableFunc(metas, func(a, b Meta) int {
+			if (a.Extends != "") != (b.Extends != "") {
+				if a.Extends != "" {
+					return -1
+				}
+				return 1
+			}
+			if a.Extends != "" {
+				return 0
+			}
+			return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Format, b.Format))
+		})
+		out[name] = metas
+	}
+	return out, nil
+}
+
+// normalizeMeta resets the fields of meta that have no effect.
+func normalizeMeta(meta Meta) Meta {
+	if meta.Extends != "" {
+		return Meta{Extends: meta.Extends}
+	}
+	if len(meta.Funcs) == 0 {
+		meta.Funcs = nil
+	}
+	if len(meta.Annotations) == 0 {
+		meta.Annotations = nil
+	}
+	if meta.Format == FormatHTML {
+		meta.Format = ""
+	}
+	return meta
+}
@@ -0,0 +1,166 @@
+package tplx
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"maps"
+	"time"
+)
+
+// Option configures optional behavior of a renderer created by NewRenderer.
+type Option func(*config)
+
+// config collects the settings applied by a set of Option values.
+type config struct {
+	funcs            template.FuncMap
+	middlewares      []RenderMiddleware
+	execErrorHandler func(name string, err error) error
+	transforms       []func(text []byte) []byte
+	auditUserKey     any
+	critical         map[string]time.Duration
+	criticalLimit    time.Duration
+	retainSource     bool
+	logger           *slog.Logger
+	debug            bool
+	integrity        map[string]string
+	formats          map[string]Renderer
+	missingKey       string
+	outputLimit      int64
+	renderTimeout    time.Duration
+	bufferPool       bool
+	bufferGrowth     bufferGrowth
+	hotReload        bool
+	localeSelector   func(ctx context.Context) string
+	postProcessors   []PostProcessor
+	parseAttempts    int
+	parseRetryDelay  time.Duration
+	xhtmlSpec        Spec
+	charsetDetection bool
+	delims           [2]string
+	contextInjection bool
+	funcTimeouts     map[string]time.Duration
+	crossIncludes    bool
+	sitemap          map[string]SitemapEntry
+	mocks            map[string]any
+
+	// err records the first invalid option, which is reported by
+	// NewRenderer.
+	err error
+}
+
+// RenderHandler renders the named template to w. It is the signature of each
+// step in the render pipeline of a renderer.
+type RenderHandler func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error
+
+// RenderMiddleware wraps a RenderHandler, like HTTP middleware wraps an
+// http.Handler. A middleware may inspect or modify the arguments before
+// calling next, replace the writer, e.g. to buffer or post-process output, or
+// inspect the returned error.
+type RenderMiddleware func(next RenderHandler) RenderHandler
+
+// fail records err unless an earlier option has failed.
+func (c *config) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// addFuncs adds fns to the global template functions.
+func (c *config) addFuncs(fns template.FuncMap) {
+	if c.funcs == nil {
+		c.funcs = make(template.FuncMap, len(fns))
+	}
+	maps.Copy(c.funcs, fns)
+}
+
+// chain wraps the final render step with all registered middlewares. The
+// first registered middleware is the outermost one.
+func (c *config) chain(final RenderHandler) RenderHandler {
+	h := final
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		h = c.middlewares[i](h)
+	}
+	return h
+}
+
+// builtins wraps h with the render steps configured through dedicated options
+// rather than middlewares. Since these are plain settings, a later option
+// replaces an earlier one instead of adding another step.
+func (c *config) builtins(h RenderHandler) RenderHandler {
+	if len(c.postProcessors) > 0 {
+		h = postProcess(h, c.postProcessors)
+	}
+	h = limitOutput(h, c.outputLimit)
+	untimed := h
+	if c.renderTimeout > 0 {
+		h = timeoutRender(h, c.renderTimeout)
+	}
+	if len(c.critical) > 0 {
+		h = c.criticalRender(h, untimed)
+	}
+	if c.bufferPool {
+		h = bufferRender(h, &c.bufferGrowth)
+	}
+	return h
+}
+
+// WithExecErrorHandler sets a function that is called with the name of the
+// top-level template and the error whenever template execution fails.
+//
+// The error returned by fn replaces the original one. If fn returns nil, the
+// render is treated as successful and the output written up to the point of
+// failure is kept. Since html/template cannot resume execution after an
+// error, this is how resilient templates can tolerate failures such as
+// nil-pointer dereferences instead of aborting the entire response.
+func WithExecErrorHandler(fn func(name string, err error) error) Option {
+	return func(c *config) {
+		c.execErrorHandler = fn
+	}
+}
+
+// WithDelims sets the action delimiters of all templates to left and right,
+// as with template.Delims, including the templates of WithXHTMLFallback and
+// the plain-text versions of NewEmailRenderer. Empty delimiters select the defaults, {{ and }}.
+func WithDelims(left, right string) Option {
+	return func(c *config) {
+		c.delims = [2]string{left, right}
+	}
+}
+
+// WithMissingKey sets the behavior of templates when a map is indexed with a
+// key that is not present, as described by the missingkey option of
+// text/template. The mode is one of "default", "invalid", "zero" or "error".
+// Like WithDelims, it also applies to the templates parsed with text/template.
+// NewRenderer returns an error wrapping ErrInvalidOption if mode is none of
+// these.
+func WithMissingKey(mode string) Option {
+	return func(c *config) {
+		switch mode {
+		case "default", "invalid", "zero", "error":
+			c.missingKey = mode
+		default:
+			c.fail(fmt.Errorf("%w: unknown missingkey mode %q", ErrInvalidOption, mode))
+		}
+	}
+}
+
+// Chain adds middlewares to the render pipeline. The middlewares are applied in
+// order, so the first one is the outermost and sees each render first. Multiple
+// Chain options, as well as other options that hook into rendering, are
+// composed in the order in which they are passed to NewRenderer.
+func Chain(middlewares ...RenderMiddleware) Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
@@ -0,0 +1,20 @@
+package tplx
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithMissingKey(t *testing.T) {
+	for _, mode := range []string{"default", "invalid", "zero", "error"} {
+		if _, err := NewRenderer(pageFS, pageSpec, nil, WithMissingKey(mode)); err != nil {
+			t.Errorf("%q: %v", mode, err)
+		}
+	}
+
+	for _, mode := range []string{"", "ERROR", "panic"} {
+		if _, err := NewRenderer(pageFS, pageSpec, nil, WithMissingKey(mode)); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%q: got %v, want ErrInvalidOption", mode, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package tplx
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// Page is a single page of a paginated slice.
+//
+// Items holds the elements of the current page. Total is the number of pages
+// and Current is the 1-based number of the current page. HasNext and HasPrev
+// report whether there are pages after or before the current page.
+type Page struct {
+	Items   []any
+	Total   int
+	Current int
+	HasNext bool
+	HasPrev bool
+}
+
+// PaginationFuncMap returns template functions for paginating slices.
+//
+// The returned map contains the following functions:
+//
+//   - paginate(items, page, pageSize int) Page returns the given 1-based page of
+//     items, which must be a slice or an array. Out-of-range pages are clamped.
+//   - pageURL(page int) string returns baseURL with the page query parameter
+//     set to page.
+//   - pageRange(current, total, window int) []int returns the page numbers at
+//     most window pages before and after current, bounded by 1 and total.
+func PaginationFuncMap(baseURL string) template.FuncMap {
+	return template.FuncMap{
+		"paginate":  paginate,
+		"pageURL":   func(page int) (string, error) { return pageURL(baseURL, page) },
+		"pageRange": pageRange,
+	}
+}
+
+// WithPaginationFuncs registers the functions of PaginationFuncMap as global
+// template functions.
+func WithPaginationFuncs(baseURL string) Option {
+	return func(c *config) {
+		c.addFuncs(PaginationFuncMap(baseURL))
+	}
+}
+
+func paginate(items any, page, pageSize int) (Page, error) {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return Page{}, fmt.Errorf("cannot paginate %T", items)
+	}
+
+	if pageSize < 1 {
+		return Page{}, fmt.Errorf("invalid page size %d", pageSize)
+	}
+
+	n := v.Len()
+	total := max((n+pageSize-1)/pageSize, 1)
+	page = min(max(page, 1), total)
+
+	start := (page - 1) * pageSize
+	end := min(start+pageSize, n)
+
+	s := make([]any, 0, end-start)
+	for i := start; i < end; i++ {
+		s = append(s, v.Index(i).Interface())
+	}
+
+	return Page{
+		Items:   s,
+		Total:   total,
+		Current: page,
+		HasNext: page < total,
+		HasPrev: page > 1,
+	}, nil
+}
+
+func pageURL(baseURL string, page int) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	q.Set("page", strconv.Itoa(page))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func pageRange(current, total, window int) []int {
+	first := max(current-window, 1)
+	last := min(current+window, total)
+
+	var pages []int
+	for p := first; p <= last; p++ {
+		pages = append(pages, p)
+	}
+	return pages
+}
@@ -0,0 +1,55 @@
+package tplx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ParseError describes a failure to load a single template.
+//
+// Template is the top-level template that failed to load. Fragment is the
+// fragment whose file could not be read or parsed, and is empty if the error
+// concerns the top-level template as a whole. Err is the underlying error.
+type ParseError struct {
+	Template string
+	Fragment string
+	Err      error
+}
+
+func (e ParseError) Error() string {
+	if e.Fragment == "" {
+		return fmt.Sprintf("template %q: %v", e.Template, e.Err)
+	}
+	return fmt.Sprintf("template %q, fragment %q: %v", e.Template, e.Fragment, e.Err)
+}
+
+func (e ParseError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError collects the errors of all templates that failed to load, so that
+// they can be fixed in one go.
+type MultiError []error
+
+func (e MultiError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e MultiError) Unwrap() []error {
+	return e
+}
+
+// asParseError wraps err in a ParseError for the top-level template name,
+// unless it already is one.
+func asParseError(name string, err error) error {
+	var pe ParseError
+	if errors.As(err, &pe) {
+		return err
+	}
+	return ParseError{Template: name, Err: err}
+}
@@ -0,0 +1,165 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"sync"
+)
+
+var (
+	// ErrPoolClosed is returned for requests submitted to a closed
+	// RenderPool.
+	ErrPoolClosed = errors.New("render pool is closed")
+
+	// ErrPoolFull is returned for requests submitted to a RenderPool whose
+	// queue is full.
+	ErrPoolFull = errors.New("render pool queue is full")
+)
+
+// RenderRequest describes a render submitted to a RenderPool.
+type RenderRequest struct {
+	Name  string
+	Data  any
+	Funcs template.FuncMap
+}
+
+// RenderResponse is the result of a RenderRequest. Output holds the rendered
+// template unless Err is set.
+type RenderResponse struct {
+	Output []byte
+	Err    error
+}
+
+// RenderPool renders templates on a fixed number of worker goroutines, each of
+// which reuses its own buffer across renders, much like database/sql reuses
+// connections. This bounds the concurrency of rendering and avoids growing a
+// new buffer for every render.
+type RenderPool struct {
+	r    Renderer
+	jobs chan job
+	quit chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type job struct {
+	ctx  context.Context
+	req  RenderRequest
+	resp chan RenderResponse
+}
+
+// PoolOption configures a RenderPool.
+type PoolOption func(*poolConfig)
+
+type poolConfig struct {
+	queue int
+}
+
+// WithPoolQueue sets the number of requests that may wait for a worker. A
+// request submitted while the queue is full fails with ErrPoolFull. The
+// default queue holds as many requests as the pool has workers.
+func WithPoolQueue(n int) PoolOption {
+	return func(c *poolConfig) {
+		c.queue = n
+	}
+}
+
+// NewRenderPool starts a RenderPool of size workers rendering with r. The pool
+// must be closed with Close when it is no longer needed.
+func NewRenderPool(r Renderer, size int, opts ...PoolOption) *RenderPool {
+	c := poolConfig{queue: size}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	p := &RenderPool{
+		r:    r,
+		jobs: make(chan job, max(c.queue, 0)),
+		quit: make(chan struct{}),
+	}
+
+	p.wg.Add(size)
+	for range size {
+		go p.work()
+	}
+
+	return p
+}
+
+func (p *RenderPool) work() {
+	defer p.wg.Done()
+
+	var buf bytes.Buffer
+	for {
+		select {
+		case <-p.quit:
+			return
+		case j := <-p.jobs:
+			if err := j.ctx.Err(); err != nil {
+				j.resp <- RenderResponse{Err: err}
+				continue
+			}
+
+			buf.Reset()
+			err := RenderContext(j.ctx, p.r, &buf, j.req.Name, j.req.Data, j.req.Funcs)
+			if err != nil {
+				j.resp <- RenderResponse{Err: err}
+				continue
+			}
+			j.resp <- RenderResponse{Output: bytes.Clone(buf.Bytes())}
+		}
+	}
+}
+
+// Submit enqueues a render and returns a channel on which its response is
+// delivered exactly once. Submit does not block: if no worker is free, the
+// request waits in the queue set by WithPoolQueue, and if the queue is full,
+// the response carries ErrPoolFull. If ctx is done before a worker picks up
+// the request, the response carries the context's error, and if the pool is
+// closed before, ErrPoolClosed.
+func (p *RenderPool) Submit(ctx context.Context, req RenderRequest) <-chan RenderResponse {
+	resp := make(chan RenderResponse, 1)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		resp <- RenderResponse{Err: ErrPoolClosed}
+		return resp
+	}
+
+	select {
+	case p.jobs <- job{ctx: ctx, req: req, resp: resp}:
+	default:
+		resp <- RenderResponse{Err: ErrPoolFull}
+	}
+
+	return resp
+}
+
+// Close stops all workers after they have finished their current render.
+// Requests still waiting in the queue fail with ErrPoolClosed.
+func (p *RenderPool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		p.wg.Wait()
+		return
+	}
+	p.closed = true
+	close(p.quit)
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	for {
+		select {
+		case j := <-p.jobs:
+			j.resp <- RenderResponse{Err: ErrPoolClosed}
+		default:
+			return
+		}
+	}
+}
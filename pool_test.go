@@ -0,0 +1,111 @@
+package tplx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"sync"
+	"testing"
+)
+
+// gateRenderer blocks every render until gate is closed, after reporting on
+// started.
+type gateRenderer struct {
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func (g gateRenderer) Render(w io.Writer, name string, data any, funcs template.FuncMap) error {
+	g.started <- struct{}{}
+	<-g.gate
+	_, err := io.WriteString(w, name)
+	return err
+}
+
+// TestRenderPoolMoreJobsThanWorkers submits many more renders than there are
+// workers from concurrent goroutines, for the race detector.
+func TestRenderPoolMoreJobsThanWorkers(t *testing.T) {
+	const jobs = 64
+	p := NewRenderPool(newPageRenderer(t), 2, WithPoolQueue(jobs))
+	defer p.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, jobs)
+	for i := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := <-p.Submit(context.Background(), RenderRequest{Name: "page", Data: i})
+			if resp.Err != nil {
+				errs <- resp.Err
+				return
+			}
+			if got, want := string(resp.Output), fmt.Sprintf("<main><h1>%d</h1></main>", i); got != want {
+				errs <- fmt.Errorf("got %q, want %q", got, want)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestRenderPoolFull(t *testing.T) {
+	g := gateRenderer{started: make(chan struct{}, 2), gate: make(chan struct{})}
+	p := NewRenderPool(g, 1, WithPoolQueue(1))
+	defer p.Close()
+
+	ctx := context.Background()
+	running := p.Submit(ctx, RenderRequest{Name: "running"})
+	<-g.started
+	queued := p.Submit(ctx, RenderRequest{Name: "queued"})
+
+	if resp := <-p.Submit(ctx, RenderRequest{Name: "rejected"}); !errors.Is(resp.Err, ErrPoolFull) {
+		t.Errorf("full queue: got %v, want ErrPoolFull", resp.Err)
+	}
+
+	close(g.gate)
+	for name, resp := range map[string]<-chan RenderResponse{"running": running, "queued": queued} {
+		r := <-resp
+		if r.Err != nil {
+			t.Errorf("%s: %v", name, r.Err)
+		} else if string(r.Output) != name {
+			t.Errorf("%s: got %q", name, r.Output)
+		}
+	}
+}
+
+func TestRenderPoolCanceledWhileQueued(t *testing.T) {
+	g := gateRenderer{started: make(chan struct{}, 2), gate: make(chan struct{})}
+	p := NewRenderPool(g, 1, WithPoolQueue(1))
+	defer p.Close()
+
+	running := p.Submit(context.Background(), RenderRequest{Name: "running"})
+	<-g.started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	queued := p.Submit(ctx, RenderRequest{Name: "queued"})
+	cancel()
+	close(g.gate)
+
+	if resp := <-running; resp.Err != nil {
+		t.Errorf("running: %v", resp.Err)
+	}
+	if resp := <-queued; !errors.Is(resp.Err, context.Canceled) {
+		t.Errorf("queued: got %v, want context.Canceled", resp.Err)
+	}
+}
+
+func TestRenderPoolClosed(t *testing.T) {
+	p := NewRenderPool(newPageRenderer(t), 1)
+	p.Close()
+	p.Close()
+
+	if resp := <-p.Submit(context.Background(), RenderRequest{Name: "page"}); !errors.Is(resp.Err, ErrPoolClosed) {
+		t.Errorf("got %v, want ErrPoolClosed", resp.Err)
+	}
+}
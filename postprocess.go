@@ -0,0 +1,61 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"io"
+)
+
+// PostProcessor transforms the rendered output of a template, e.g. to minify
+// or validate it. The name parameter is the top-level template, allowing
+// name-specific behavior.
+type PostProcessor interface {
+	Process(name string, input []byte) ([]byte, error)
+}
+
+// WithPostProcessor renders templates into a buffer and passes the output
+// through pp before writing it. Multiple post-processors are applied in the
+// order they are registered, each receiving the output of the previous one.
+// If a post-processor fails, its error is returned and nothing is written.
+func WithPostProcessor(pp PostProcessor) Option {
+	return func(c *config) {
+		c.postProcessors = append(c.postProcessors, pp)
+	}
+}
+
+func postProcess(next RenderHandler, pps []PostProcessor) RenderHandler {
+	return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+		var buf bytes.Buffer
+		if err := next(ctx, &buf, name, data, funcs); err != nil {
+			return err
+		}
+
+		out := buf.Bytes()
+		for _, pp := range pps {
+			var err error
+			if out, err = pp.Process(name, out); err != nil {
+				return err
+			}
+		}
+
+		_, err := w.Write(out)
+		return err
+	}
+}
+
+// NoopPostProcessor is a PostProcessor that returns its input unchanged.
+type NoopPostProcessor struct{}
+
+// Process returns input unchanged.
+func (NoopPostProcessor) Process(_ string, input []byte) ([]byte, error) {
+	return input, nil
+}
+
+// FuncPostProcessor adapts an ordinary function to a PostProcessor.
+type FuncPostProcessor func(name string, input []byte) ([]byte, error)
+
+// Process calls fn(name, input).
+func (fn FuncPostProcessor) Process(name string, input []byte) ([]byte, error) {
+	return fn(name, input)
+}
@@ -0,0 +1,27 @@
+package tplx
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"runtime/pprof"
+)
+
+// WithPprofLabels labels each render with the pprof label template set to
+// the name of the rendered template, so that CPU and goroutine profiles
+// attribute time spent rendering to individual templates instead of
+// anonymous template execution frames. The labels are also set on the context
+// passed down the render pipeline.
+func WithPprofLabels() Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, func(next RenderHandler) RenderHandler {
+			return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+				var err error
+				pprof.Do(ctx, pprof.Labels("template", name), func(ctx context.Context) {
+					err = next(ctx, w, name, data, funcs)
+				})
+				return err
+			}
+		})
+	}
+}
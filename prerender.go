@@ -0,0 +1,86 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"io"
+	"sync"
+)
+
+// PreRendered is a Renderer that serves some templates from output rendered
+// ahead of time and delegates all others to an underlying renderer.
+type PreRendered struct {
+	r     Renderer
+	names []string
+	data  map[string]any
+	funcs template.FuncMap
+
+	mu     sync.RWMutex
+	output map[string][]byte
+}
+
+// PreRender renders the named templates once and returns a Renderer that
+// serves them from memory, which suits fully static pages such as an about
+// page or error pages. All other templates are delegated to r.
+//
+// The data parameter maps template names to the data used for pre-rendering
+// them; names without an entry are rendered with nil data. The funcs parameter
+// is used for all pre-rendered templates. When a pre-rendered template is
+// rendered later on, the data and funcs passed to Render are ignored.
+//
+// The returned Renderer is a *PreRendered. Returns an error if any of the
+// templates cannot be rendered.
+func PreRender(r Renderer, names []string, data map[string]any, funcs template.FuncMap) (Renderer, error) {
+	p := &PreRendered{
+		r:     r,
+		names: names,
+		data:  data,
+		funcs: funcs,
+	}
+
+	if err := p.RefreshPreRendered(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// RefreshPreRendered renders all pre-rendered templates again, e.g. after the
+// underlying templates have been reloaded. The stored output is only replaced
+// if all templates render successfully.
+func (p *PreRendered) RefreshPreRendered(ctx context.Context) error {
+	output := make(map[string][]byte, len(p.names))
+	for _, name := range p.names {
+		var buf bytes.Buffer
+		if err := RenderContext(ctx, p.r, &buf, name, p.data[name], p.funcs); err != nil {
+			return err
+		}
+		output[name] = buf.Bytes()
+	}
+
+	p.mu.Lock()
+	p.output = output
+	p.mu.Unlock()
+	return nil
+}
+
+// Render writes the output of a pre-rendered template to w, or renders any
+// other template using the underlying renderer.
+func (p *PreRendered) Render(w io.Writer, name string, data any, funcs template.FuncMap) error {
+	return p.RenderContext(context.Background(), w, name, data, funcs)
+}
+
+// RenderContext is like Render but passes ctx to the underlying renderer.
+func (p *PreRendered) RenderContext(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+	p.mu.RLock()
+	out, ok := p.output[name]
+	p.mu.RUnlock()
+
+	if !ok {
+		return RenderContext(ctx, p.r, w, name, data, funcs)
+	}
+
+	_, err := w.Write(out)
+	return err
+}
@@ -0,0 +1,167 @@
+package tplx
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Priority is the priority level of a render submitted to a PriorityRenderer.
+type Priority int
+
+// Priority levels, from the most to the least urgent.
+const (
+	PriorityHigh Priority = iota
+	PriorityNormal
+	PriorityLow
+)
+
+const numPriorities = int(PriorityLow) + 1
+
+// Priority job states.
+const (
+	jobQueued int32 = iota
+	jobRunning
+	jobCanceled
+)
+
+type priorityJob struct {
+	ctx   context.Context
+	w     io.Writer
+	name  string
+	data  any
+	funcs template.FuncMap
+	state atomic.Int32
+	done  chan error
+}
+
+// PriorityRenderer renders templates on a fixed number of worker goroutines,
+// queueing renders by priority. Workers always take high priority renders
+// before normal ones, and normal before low ones, so that user-facing
+// templates such as checkout or login pages are not held up by background
+// renders.
+type PriorityRenderer struct {
+	r      Renderer
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queues [numPriorities][]*priorityJob
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewPriorityRenderer starts a PriorityRenderer of size workers rendering
+// with r. It must be closed with Close when it is no longer needed.
+func NewPriorityRenderer(r Renderer, size int) *PriorityRenderer {
+	p := &PriorityRenderer{r: r}
+	p.cond = sync.NewCond(&p.mu)
+
+	p.wg.Add(size)
+	for range size {
+		go p.work()
+	}
+
+	return p
+}
+
+// next blocks until a job is queued and returns the most urgent one, or nil
+// once the renderer is closed.
+func (p *PriorityRenderer) next() *priorityJob {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for {
+		if p.closed {
+			return nil
+		}
+
+		for i := range p.queues {
+			if len(p.queues[i]) > 0 {
+				j := p.queues[i][0]
+				p.queues[i][0] = nil
+				p.queues[i] = p.queues[i][1:]
+				return j
+			}
+		}
+
+		p.cond.Wait()
+	}
+}
+
+func (p *PriorityRenderer) work() {
+	defer p.wg.Done()
+
+	for {
+		j := p.next()
+		if j == nil {
+			return
+		}
+
+		if !j.state.CompareAndSwap(jobQueued, jobRunning) {
+			continue
+		}
+		j.done <- RenderContext(j.ctx, p.r, j.w, j.name, j.data, j.funcs)
+	}
+}
+
+// PriorityRender enqueues a render at the given priority and blocks until it
+// has completed or ctx is done. A render that has already started is always
+// waited for, since it may still write to w.
+//
+// Returns the error of the render, the context's error if ctx is done before
+// the render starts, or ErrPoolClosed if the renderer is closed.
+func (p *PriorityRenderer) PriorityRender(ctx context.Context, priority Priority, w io.Writer, name string, data any, funcs template.FuncMap) error {
+	priority = min(max(priority, PriorityHigh), PriorityLow)
+
+	j := &priorityJob{ctx: ctx, w: w, name: name, data: data, funcs: funcs, done: make(chan error, 1)}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrPoolClosed
+	}
+	p.queues[priority] = append(p.queues[priority], j)
+	p.mu.Unlock()
+	p.cond.Signal()
+
+	select {
+	case err := <-j.done:
+		return err
+	case <-ctx.Done():
+		if j.state.CompareAndSwap(jobQueued, jobCanceled) {
+			return ctx.Err()
+		}
+		return <-j.done
+	}
+}
+
+// Render renders at PriorityNormal.
+func (p *PriorityRenderer) Render(w io.Writer, name string, data any, funcs template.FuncMap) error {
+	return p.PriorityRender(context.Background(), PriorityNormal, w, name, data, funcs)
+}
+
+// RenderContext renders at PriorityNormal.
+func (p *PriorityRenderer) RenderContext(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+	return p.PriorityRender(ctx, PriorityNormal, w, name, data, funcs)
+}
+
+// Close stops all workers after they have finished their current render.
+// Renders still queued fail with ErrPoolClosed.
+func (p *PriorityRenderer) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		for i := range p.queues {
+			for _, j := range p.queues[i] {
+				if j.state.CompareAndSwap(jobQueued, jobCanceled) {
+					j.done <- ErrPoolClosed
+				}
+			}
+			p.queues[i] = nil
+		}
+	}
+	p.mu.Unlock()
+	p.cond.Broadcast()
+	p.wg.Wait()
+}
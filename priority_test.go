@@ -0,0 +1,157 @@
+package tplx
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// orderRenderer records the order of renders. Renders of "block" wait until
+// gate is closed.
+type orderRenderer struct {
+	gate    chan struct{}
+	started chan struct{}
+
+	mu    sync.Mutex
+	names []string
+}
+
+func (o *orderRenderer) Render(w io.Writer, name string, data any, funcs template.FuncMap) error {
+	if name == "block" {
+		o.started <- struct{}{}
+		<-o.gate
+	}
+	o.mu.Lock()
+	o.names = append(o.names, name)
+	o.mu.Unlock()
+	return nil
+}
+
+func newOrderRenderer() *orderRenderer {
+	return &orderRenderer{gate: make(chan struct{}), started: make(chan struct{}, 1)}
+}
+
+// waitQueued waits until n renders are queued in p.
+func waitQueued(t *testing.T, p *PriorityRenderer, n int) {
+	t.Helper()
+	for range 1000 {
+		p.mu.Lock()
+		queued := 0
+		for _, q := range p.queues {
+			queued += len(q)
+		}
+		p.mu.Unlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%d renders were not queued", n)
+}
+
+func TestPriorityRendererOrder(t *testing.T) {
+	o := newOrderRenderer()
+	p := NewPriorityRenderer(o, 1)
+	defer p.Close()
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	render := func(priority Priority, name string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := p.PriorityRender(ctx, priority, io.Discard, name, nil, nil); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	render(PriorityNormal, "block")
+	<-o.started
+	render(PriorityLow, "low")
+	waitQueued(t, p, 1)
+	render(PriorityNormal, "normal")
+	waitQueued(t, p, 2)
+	render(PriorityHigh, "high")
+	waitQueued(t, p, 3)
+
+	close(o.gate)
+	wg.Wait()
+
+	if want := []string{"block", "high", "normal", "low"}; !slices.Equal(o.names, want) {
+		t.Errorf("got order %v, want %v", o.names, want)
+	}
+}
+
+func TestPriorityRendererCancel(t *testing.T) {
+	o := newOrderRenderer()
+	p := NewPriorityRenderer(o, 1)
+	defer p.Close()
+
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- p.PriorityRender(context.Background(), PriorityHigh, io.Discard, "block", nil, nil)
+	}()
+	<-o.started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		canceled <- p.PriorityRender(ctx, PriorityHigh, io.Discard, "canceled", nil, nil)
+	}()
+	waitQueued(t, p, 1)
+	cancel()
+	if err := <-canceled; !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled render: got %v, want context.Canceled", err)
+	}
+
+	close(o.gate)
+	if err := <-blocked; err != nil {
+		t.Errorf("running render: %v", err)
+	}
+	if err := p.Render(io.Discard, "after", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(o.names, "canceled") {
+		t.Errorf("canceled render ran: %v", o.names)
+	}
+}
+
+func TestPriorityRendererClose(t *testing.T) {
+	o := newOrderRenderer()
+	p := NewPriorityRenderer(o, 1)
+
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- p.PriorityRender(context.Background(), PriorityHigh, io.Discard, "block", nil, nil)
+	}()
+	<-o.started
+
+	queued := make(chan error, 1)
+	go func() {
+		queued <- p.PriorityRender(context.Background(), PriorityLow, io.Discard, "queued", nil, nil)
+	}()
+	waitQueued(t, p, 1)
+
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		close(closed)
+	}()
+	if err := <-queued; !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("queued render: got %v, want ErrPoolClosed", err)
+	}
+	close(o.gate)
+	<-closed
+	if err := <-blocked; err != nil {
+		t.Errorf("running render: %v", err)
+	}
+	if err := p.Render(io.Discard, "after", nil, nil); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("after Close: got %v, want ErrPoolClosed", err)
+	}
+}
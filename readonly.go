@@ -0,0 +1,110 @@
+package tplx
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"slices"
+)
+
+// ReadOnlyRenderer is a view of a Renderer that can render and inspect
+// templates but not modify them.
+type ReadOnlyRenderer interface {
+	Renderer
+	Has(name string) bool
+	Names() []string
+	TemplateBlocks(name string) ([]string, error)
+}
+
+// ReadOnly narrows r to a ReadOnlyRenderer. The returned value exposes no
+// other methods of r, so it cannot be type-asserted back to a mutable
+// interface such as PatchableRenderer.
+//
+// If r does not support inspection, Has reports false, Names returns nil and
+// TemplateBlocks returns ErrUnknownTemplate.
+func ReadOnly(r Renderer) ReadOnlyRenderer {
+	return readOnly{r: r}
+}
+
+type readOnly struct {
+	r Renderer
+}
+
+func (ro readOnly) Render(w io.Writer, name string, data any, funcs template.FuncMap) error {
+	return ro.r.Render(w, name, data, funcs)
+}
+
+func (ro readOnly) RenderContext(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+	return RenderContext(ctx, ro.r, w, name, data, funcs)
+}
+
+func (ro readOnly) Has(name string) bool {
+	if i, ok := ro.r.(interface{ Has(string) bool }); ok {
+		return i.Has(name)
+	}
+	return false
+}
+
+func (ro readOnly) Names() []string {
+	if i, ok := ro.r.(interface{ Names() []string }); ok {
+		return i.Names()
+	}
+	return nil
+}
+
+func (ro readOnly) TemplateBlocks(name string) ([]string, error) {
+	if i, ok := ro.r.(interface {
+		TemplateBlocks(string) ([]string, error)
+	}); ok {
+		return i.TemplateBlocks(name)
+	}
+	return nil, ErrUnknownTemplate
+}
+
+// Has reports whether a top-level template with the given name exists.
+func (r *renderer) Has(name string) bool {
+	_, ok := r.lookup(name)
+	return ok
+}
+
+// Names returns the names of all top-level templates in sorted order.
+func (r *renderer) Names() []string {
+	m := *r.m.Load()
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// TemplateBlocks returns the sorted names of all sub-templates defined within
+// the top-level template name, excluding the top-level template itself.
+//
+// Returns ErrUnknownTemplate if the template does not exist.
+func (r *renderer) TemplateBlocks(name string) ([]string, error) {
+	e, ok := r.lookup(name)
+	if !ok {
+		return nil, ErrUnknownTemplate
+	}
+
+	var blocks []string
+	for _, t := range e.base.Templates() {
+		if t.Name() != name {
+			blocks = append(blocks, t.Name())
+		}
+	}
+	slices.Sort(blocks)
+	return blocks, nil
+}
+
+// FuncNames returns the sorted names of the functions registered for the
+// top-level template name, including global functions, or nil if the template
+// does not exist.
+func (r *renderer) FuncNames(name string) []string {
+	e, ok := r.lookup(name)
+	if !ok {
+		return nil
+	}
+	return slices.Clone(e.funcs)
+}
@@ -0,0 +1,63 @@
+package tplx
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Registry is a set of named renderers, allowing independent parts of an
+// application such as plugins to share renderers by name. The zero value is
+// ready to use, and a Registry is safe for concurrent use.
+type Registry struct {
+	mu sync.RWMutex
+	m  map[string]Renderer
+}
+
+// DefaultRegistry is the registry used by RegisterDefault and GetDefault.
+var DefaultRegistry = &Registry{}
+
+// Register adds r under the given name. Like database/sql.Register, it panics
+// if r is nil or a renderer is already registered under name.
+func (reg *Registry) Register(name string, r Renderer) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if r == nil {
+		panic("tplx: Register renderer is nil")
+	}
+	if _, dup := reg.m[name]; dup {
+		panic("tplx: Register called twice for renderer " + name)
+	}
+
+	if reg.m == nil {
+		reg.m = make(map[string]Renderer)
+	}
+	reg.m[name] = r
+}
+
+// Get returns the renderer registered under name.
+func (reg *Registry) Get(name string) (Renderer, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	r, ok := reg.m[name]
+	return r, ok
+}
+
+// MustGet is like Get but panics if no renderer is registered under name.
+func (reg *Registry) MustGet(name string) Renderer {
+	r, ok := reg.Get(name)
+	if !ok {
+		panic(fmt.Sprintf("tplx: unknown renderer %q", name))
+	}
+	return r
+}
+
+// RegisterDefault adds r to DefaultRegistry under the given name.
+func RegisterDefault(name string, r Renderer) {
+	DefaultRegistry.Register(name, r)
+}
+
+// GetDefault returns the renderer registered in DefaultRegistry under name.
+func GetDefault(name string) (Renderer, bool) {
+	return DefaultRegistry.Get(name)
+}
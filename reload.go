@@ -0,0 +1,122 @@
+package tplx
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"time"
+)
+
+// ReloadableRenderer is a Renderer that can reparse its templates from the
+// file system it was created with.
+type ReloadableRenderer interface {
+	Renderer
+
+	// Reload reparses the named top-level templates, or all templates of the
+	// spec if no names are given.
+	Reload(names ...string) error
+
+	// Paths returns the sorted paths of all template files, relative to the
+	// file system of the renderer.
+	Paths() []string
+
+	// Dependents returns the sorted names of all top-level templates that
+	// include the file at path, directly or through Extends.
+	Dependents(path string) []string
+}
+
+// WithHotReload reparses a template from the file system before each render,
+// so that changes show up without restarting the process. Templates added
+// with CompileTemplate are not affected. Since every render pays for parsing,
+// this is only meant for development.
+func WithHotReload() Option {
+	return func(c *config) {
+		c.hotReload = true
+	}
+}
+
+// WithRetryOnParseError retries parsing a template up to maxAttempts times in
+// total, waiting delay between attempts, when it fails to parse during Reload.
+// This covers reloads triggered by a file watcher while a file is still being
+// written, in which case the truncated file fails to parse. The error of the
+// last attempt is returned.
+func WithRetryOnParseError(maxAttempts int, delay time.Duration) Option {
+	return func(c *config) {
+		c.parseAttempts = maxAttempts
+		c.parseRetryDelay = delay
+	}
+}
+
+// reparse parses the top-level template name from the file system, retrying
+// on parse errors as configured by WithRetryOnParseError.
+func (r *renderer) reparse(name string) (*entry, error) {
+	for attempt := 1; ; attempt++ {
+		e, err := r.c.parseEntry(r.fsys, r.spec, name, r.funcs)
+
+		var pe ParseError
+		if err == nil || attempt >= r.c.parseAttempts || !errors.As(err, &pe) {
+			return e, err
+		}
+		time.Sleep(r.c.parseRetryDelay)
+	}
+}
+
+// Reload reparses the named top-level templates from the file system, or all
+// templates of the spec if no names are given. The templates are swapped in
+// atomically once all of them have been parsed, so if any of them fails to
+// parse, none are replaced.
+//
+// Returns ErrUnknownTemplate if a name is not part of the spec, or an error if
+// a template cannot be parsed.
+func (r *renderer) Reload(names ...string) error {
+	if len(names) == 0 {
+		names = slices.Collect(maps.Keys(r.spec))
+	}
+
+	if r.c.integrity != nil {
+		if err := VerifySpec(r.fsys, r.spec, r.c.integrity); err != nil {
+			return err
+		}
+	}
+
+	entries := make(map[string]*entry, len(names))
+	for _, name := range names {
+		if _, ok := r.spec[name]; !ok {
+			return ErrUnknownTemplate
+		}
+
+		e, err := r.reparse(name)
+		if err != nil {
+			return err
+		}
+		entries[name] = e
+	}
+
+	return r.update(func(m map[string]*entry) error {
+		maps.Copy(m, entries)
+		return nil
+	})
+}
+
+// Paths returns the sorted paths of all template files in the spec.
+func (r *renderer) Paths() []string {
+	return specPaths(r.spec)
+}
+
+// Dependents returns the sorted names of all top-level templates that include
+// the file at path, directly or through Extends.
+func (r *renderer) Dependents(path string) []string {
+	var names []string
+	for name := range r.spec {
+		metas, err := resolveExtends(r.spec, name)
+		if err != nil {
+			continue
+		}
+
+		if slices.ContainsFunc(metas, func(meta Meta) bool { return meta.Path == path }) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,57 @@
+package tplx
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// RenderResult is a deferred render that writes its response when served.
+//
+// Handlers can return a RenderResult instead of writing to the response right
+// away, allowing middleware to inspect it or adjust Status and Headers before
+// it is served. Name and Data specify the template and its data. Status
+// defaults to http.StatusOK, and Content-Type defaults to HTML unless set in
+// Headers.
+//
+// The renderer is taken from the request context (see NewContext), and the
+// request and response writer are made available to the render via
+// NewRequestContext and NewResponseContext.
+type RenderResult struct {
+	Name    string
+	Data    any
+	Status  int
+	Headers http.Header
+}
+
+// ServeHTTP renders the template into a buffer and writes it to w. If the
+// request context has no renderer or the render fails, no partial output is
+// written and an internal server error is returned instead.
+func (res RenderResult) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r, ok := FromContext(req.Context())
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	ctx := NewResponseContext(NewRequestContext(req.Context(), req), w)
+	if err := RenderContext(ctx, r, &buf, res.Name, res.Data, nil); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	header := w.Header()
+	for k, v := range res.Headers {
+		header[k] = v
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "text/html; charset=utf-8")
+	}
+
+	status := res.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, _ = buf.WriteTo(w)
+}
@@ -0,0 +1,53 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"io"
+	"time"
+)
+
+// WithRenderRetry retries renders that fail with a transient error, such as a
+// template function whose database query timed out.
+//
+// An error is considered transient if it, or any error it wraps, has an
+// IsTransient() bool method returning true. A render is attempted at most
+// maxAttempts times, waiting backoff(attempt) after the given failed attempt,
+// starting at 1. Output is buffered so that partial output of a failed attempt
+// is discarded. Retrying stops early when the render context is done.
+func WithRenderRetry(maxAttempts int, backoff func(attempt int) time.Duration) Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, func(next RenderHandler) RenderHandler {
+			return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+				var buf bytes.Buffer
+				for attempt := 1; ; attempt++ {
+					buf.Reset()
+					err := next(ctx, &buf, name, data, funcs)
+					if err == nil {
+						_, err = buf.WriteTo(w)
+						return err
+					}
+
+					if attempt >= maxAttempts || !isTransient(err) {
+						return err
+					}
+
+					timer := time.NewTimer(backoff(attempt))
+					select {
+					case <-ctx.Done():
+						timer.Stop()
+						return err
+					case <-timer.C:
+					}
+				}
+			}
+		})
+	}
+}
+
+func isTransient(err error) bool {
+	var t interface{ IsTransient() bool }
+	return errors.As(err, &t) && t.IsTransient()
+}
@@ -0,0 +1,95 @@
+package tplx
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrNoRenderer is returned when a RendererRouter has no renderer for a host
+// and no default renderer.
+var ErrNoRenderer = errors.New("no renderer registered for host")
+
+type hostKey struct{}
+
+// WithHost returns a copy of ctx carrying the given hostname. The hostname is
+// lowercased, as by Register, since hostnames are case-insensitive.
+func WithHost(ctx context.Context, host string) context.Context {
+	return context.WithValue(ctx, hostKey{}, strings.ToLower(host))
+}
+
+// HostFromContext returns the hostname stored in ctx by WithHost.
+func HostFromContext(ctx context.Context) (string, bool) {
+	host, ok := ctx.Value(hostKey{}).(string)
+	return host, ok
+}
+
+// HostMiddleware stores the hostname of each request in the request context,
+// where it can be picked up by a RendererRouter. Any port in the Host header
+// is removed.
+func HostMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		ctx := WithHost(req.Context(), host)
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// RendererRouter is a Renderer that dispatches to one of several renderers
+// based on the hostname stored in the render context.
+type RendererRouter struct {
+	mu       sync.RWMutex
+	hosts    map[string]Renderer
+	fallback Renderer
+}
+
+// NewRendererRouter creates a new RendererRouter. The fallback parameter
+// specifies the renderer used when no renderer is registered for a host and
+// may be nil.
+func NewRendererRouter(fallback Renderer) *RendererRouter {
+	return &RendererRouter{
+		hosts:    make(map[string]Renderer),
+		fallback: fallback,
+	}
+}
+
+// Register adds or replaces the renderer used for the given hostname.
+func (rr *RendererRouter) Register(host string, r Renderer) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	rr.hosts[strings.ToLower(host)] = r
+}
+
+// Render renders a named template using the fallback renderer, since no
+// hostname is available without a context.
+func (rr *RendererRouter) Render(w io.Writer, name string, data any, funcs template.FuncMap) error {
+	return rr.RenderContext(context.Background(), w, name, data, funcs)
+}
+
+// RenderContext renders a named template using the renderer registered for
+// the hostname in ctx, falling back to the default renderer if there is none.
+//
+// Returns ErrNoRenderer if neither a matching nor a default renderer exists.
+func (rr *RendererRouter) RenderContext(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+	rr.mu.RLock()
+	r := rr.fallback
+	if host, ok := HostFromContext(ctx); ok {
+		if hr, ok := rr.hosts[host]; ok {
+			r = hr
+		}
+	}
+	rr.mu.RUnlock()
+
+	if r == nil {
+		return ErrNoRenderer
+	}
+	return RenderContext(ctx, r, w, name, data, funcs)
+}
@@ -0,0 +1,112 @@
+package tplx
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"slices"
+)
+
+// SafeFuncList enumerates the builtin template functions that cannot be used
+// to call arbitrary code. Notably, it does not contain call.
+var SafeFuncList = []string{
+	"and", "or", "not", "len", "index", "slice",
+	"eq", "ne", "lt", "le", "gt", "ge",
+	"html", "js", "urlquery", "print", "printf", "println",
+}
+
+// builtinFuncs lists all builtin functions of text/template.
+var builtinFuncs = []string{
+	"and", "or", "not", "len", "index", "slice", "call",
+	"eq", "ne", "lt", "le", "gt", "ge",
+	"html", "js", "urlquery", "print", "printf", "println",
+}
+
+// SandboxedRenderer returns a function that wraps a renderer so that only the
+// functions named in allowed can be called during execution, which is useful
+// when rendering user-provided templates.
+//
+// Before each render, every builtin function, every function the wrapped
+// renderer reports through a FuncNames(name string) []string method, and every
+// function passed to Render is examined. Those not in allowed are replaced by
+// a stub that fails the render with an error. Since the replacements are
+// passed as per-call functions, each render works on a fresh clone of the
+// template. For renderers created by NewRenderer, the restriction is applied
+// again after all other per-call functions, such as those of WithMockFuncs,
+// and to every template included with renderTemplate. Nested sandboxes allow
+// only the functions allowed by all of them.
+func SandboxedRenderer(allowed []string) func(Renderer) Renderer {
+	return func(r Renderer) Renderer {
+		return &sandbox{r: r, allowed: slices.Clone(allowed)}
+	}
+}
+
+type sandbox struct {
+	r       Renderer
+	allowed []string
+}
+
+func (s *sandbox) Render(w io.Writer, name string, data any, funcs template.FuncMap) error {
+	return s.RenderContext(context.Background(), w, name, data, funcs)
+}
+
+func (s *sandbox) RenderContext(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+	allowed := s.allowed
+	if outer, ok := ctx.Value(sandboxKey{}).([]string); ok {
+		allowed = slices.DeleteFunc(slices.Clone(allowed), func(fname string) bool {
+			return !slices.Contains(outer, fname)
+		})
+	}
+	ctx = context.WithValue(ctx, sandboxKey{}, allowed)
+
+	// A renderer created by NewRenderer restricts the functions of every
+	// template it executes itself, after applying its mocks.
+	if _, ok := s.r.(*renderer); ok {
+		return RenderContext(ctx, s.r, w, name, data, funcs)
+	}
+
+	var names []string
+	if fn, ok := s.r.(interface{ FuncNames(string) []string }); ok {
+		names = fn.FuncNames(name)
+	}
+	return RenderContext(ctx, s.r, w, name, data, restrictFuncs(allowed, names, funcs))
+}
+
+// sandboxKey is the context key under which a sandbox stores the functions it
+// allows, so that the renderer can restrict every template it executes.
+type sandboxKey struct{}
+
+// sandboxFuncs restricts funcs for a template with the functions names if ctx
+// belongs to a sandboxed render, and returns funcs unchanged otherwise.
+func sandboxFuncs(ctx context.Context, names []string, funcs template.FuncMap) template.FuncMap {
+	allowed, ok := ctx.Value(sandboxKey{}).([]string)
+	if !ok {
+		return funcs
+	}
+	return restrictFuncs(allowed, names, funcs)
+}
+
+// restrictFuncs returns a copy of funcs in which every builtin function, every
+// function in names and every function of funcs itself that is not in allowed
+// is replaced by a stub failing the render.
+func restrictFuncs(allowed, names []string, funcs template.FuncMap) template.FuncMap {
+	names = append(slices.Clone(builtinFuncs), names...)
+	names = slices.AppendSeq(names, maps.Keys(funcs))
+
+	restricted := make(template.FuncMap, len(names))
+	maps.Copy(restricted, funcs)
+	for _, fname := range names {
+		if !slices.Contains(allowed, fname) {
+			restricted[fname] = forbiddenFunc(fname)
+		}
+	}
+	return restricted
+}
+
+func forbiddenFunc(name string) func(...any) (any, error) {
+	return func(...any) (any, error) {
+		return nil, fmt.Errorf("function %q is not allowed", name)
+	}
+}
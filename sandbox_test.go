@@ -0,0 +1,69 @@
+package tplx
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSandboxedRenderer(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.html":    {Data: []byte(`<p>{{.}}</p>`)},
+		"secret.html":  {Data: []byte(`<p>{{secret}}</p>`)},
+		"include.html": {Data: []byte(`<div>{{renderTemplate "secret" .}}</div>`)},
+	}
+	spec := Spec{
+		"page":    {{Name: "page", Path: "page.html"}},
+		"secret":  {{Name: "secret", Path: "secret.html"}},
+		"include": {{Name: "include", Path: "include.html"}},
+	}
+	funcs := template.FuncMap{"secret": func() string { return "s3cr3t" }}
+	allowed := []string{"renderTemplate"}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		tmpl    string
+		want    string
+		wantErr string
+	}{
+		{name: "allowed", tmpl: "page", want: "<p>ok</p>"},
+		{name: "blocked", tmpl: "secret", wantErr: `function "secret" is not allowed`},
+		{name: "blocked in include", tmpl: "include", wantErr: `function "secret" is not allowed`},
+		{
+			name:    "blocked despite mock",
+			opts:    []Option{WithMockFuncs(map[string]any{"secret": func() string { return "mocked" }})},
+			tmpl:    "secret",
+			wantErr: `function "secret" is not allowed`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewRenderer(fsys, spec, funcs, append(tt.opts, WithCrossTemplateIncludes())...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sr := SandboxedRenderer(allowed)(r)
+
+			var buf bytes.Buffer
+			err = sr.Render(&buf, tt.tmpl, "ok", nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				if strings.Contains(buf.String(), "s3cr3t") {
+					t.Errorf("blocked function ran: %q", buf.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"net/http"
+)
+
+// ServeTemplate renders the named template using r as the response to req,
+// with an ETag header so that clients can revalidate their cached copy.
+//
+// If r provides ETag, as renderers created by NewRenderer do, the tag is a
+// hash of the template's tag and the JSON encoding of data, so a GET or HEAD
+// request whose If-None-Match header matches is answered with 304 Not
+// Modified without rendering. For renderers created by NewRenderer, the hash
+// also covers the locale chosen by WithLocaleSelector, the localized template
+// and the format negotiated by WithXHTMLFallback, which adds Vary: Accept to
+// the response; with WithContextInjection, whose functions cannot be hashed,
+// the output is hashed instead. In all other cases, or if data cannot be
+// encoded as JSON, the tag is a hash of the rendered output. Since functions
+// and middlewares cannot be hashed, funcs and middlewares must not make the
+// output differ for the same data, and a locale selector reading request
+// headers calls for adding them to Vary. The Cache-Control header is set to
+// must-revalidate and the Content-Type header to HTML unless they are already
+// set.
+//
+// Returns an error if the template cannot be rendered, in which case nothing
+// is written, or if the response cannot be written.
+func ServeTemplate(r Renderer, w http.ResponseWriter, req *http.Request, name string, data any, funcs template.FuncMap) error {
+	ctx := NewResponseContext(NewRequestContext(req.Context(), req), w)
+	etag := dataETag(ctx, r, name, data)
+	if etag != "" && notModified(w, req, etag) {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := RenderContext(ctx, r, &buf, name, data, funcs); err != nil {
+		return err
+	}
+
+	if etag == "" {
+		etag = hashETag(buf.Bytes())
+		if notModified(w, req, etag) {
+			return nil
+		}
+	}
+
+	h := w.Header()
+	h.Set("ETag", etag)
+	if h.Get("Cache-Control") == "" {
+		h.Set("Cache-Control", "must-revalidate")
+	}
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", "text/html; charset=utf-8")
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// dataETag returns the entity tag of rendering name with data in ctx, or the
+// empty string if it cannot be computed without rendering.
+func dataETag(ctx context.Context, r Renderer, name string, data any) string {
+	tagger, ok := r.(interface{ ETag(string) (string, error) })
+	if !ok {
+		return ""
+	}
+
+	var variant string
+	if vr, ok := r.(interface {
+		etagVariant(context.Context, string) (string, string, bool)
+	}); ok {
+		if name, variant, ok = vr.etagVariant(ctx, name); !ok {
+			return ""
+		}
+	}
+
+	tag, err := tagger.ETag(name)
+	if err != nil {
+		return ""
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+	return hashETag([]byte(tag), []byte(variant), b)
+}
+
+// etagVariant returns the template a render of name in ctx resolves to and a
+// string identifying the locale and format it is rendered in, or false if the
+// output depends on ctx in ways that cannot be identified. Like a render, it
+// sets the response headers of the format negotiation.
+func (r *renderer) etagVariant(ctx context.Context, name string) (string, string, bool) {
+	if r.c.contextInjection {
+		return "", "", false
+	}
+
+	var locale string
+	if r.c.localeSelector != nil {
+		locale = r.c.localeSelector(ctx)
+	}
+	name = r.localize(ctx, name)
+
+	format := FormatHTML
+	if w, ok := ResponseFromContext(ctx); ok && xhtmlFromContext(r.negotiateXHTML(ctx, w, name)) {
+		format = "xhtml"
+	}
+	return name, locale + "\x00" + format, true
+}
+
+// hashETag returns a quoted entity tag hashing parts.
+func hashETag(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+		h.Write([]byte{0})
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// notModified answers req with 304 Not Modified if it is a GET or HEAD
+// request whose If-None-Match header matches etag.
+//
+// Returns whether the response was written.
+func notModified(w http.ResponseWriter, req *http.Request, etag string) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+
+	inm := req.Header.Get("If-None-Match")
+	if inm == "" || !etagMatches(inm, etag) {
+		return false
+	}
+
+	h := w.Header()
+	h.Set("ETag", etag)
+	if h.Get("Cache-Control") == "" {
+		h.Set("Cache-Control", "must-revalidate")
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
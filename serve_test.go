@@ -0,0 +1,112 @@
+package tplx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func newServeRenderer(tb testing.TB, opts ...Option) Renderer {
+	tb.Helper()
+	fsys := fstest.MapFS{
+		"page.html":    {Data: []byte(`<p>{{.}}</p>`)},
+		"page_fr.html": {Data: []byte(`<p lang="fr">{{.}}</p>`)},
+		"page.xhtml":   {Data: []byte(`<p xmlns="http://www.w3.org/1999/xhtml">{{.}}</p>`)},
+	}
+	spec := Spec{
+		"page":    {{Name: "page", Path: "page.html"}},
+		"page_fr": {{Name: "page_fr", Path: "page_fr.html"}},
+	}
+	opts = append([]Option{
+		WithLocaleSelector(func(ctx context.Context) string {
+			req, _ := RequestFromContext(ctx)
+			return req.Header.Get("X-Locale")
+		}),
+		WithXHTMLFallback(Spec{"page": {{Name: "page", Path: "page.xhtml"}}}),
+	}, opts...)
+	r, err := NewRenderer(fsys, spec, nil, opts...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return r
+}
+
+// serve answers a GET request with the given headers and returns the
+// response.
+func serve(tb testing.TB, r Renderer, data any, header map[string]string) *httptest.ResponseRecorder {
+	tb.Helper()
+	req := httptest.NewRequest("GET", "/", nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	if err := ServeTemplate(r, rec, req, "page", data, nil); err != nil {
+		tb.Fatal(err)
+	}
+	return rec
+}
+
+func TestServeTemplateNotModified(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"data etag":   nil,
+		"output etag": {WithContextInjection()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := newServeRenderer(t, opts...)
+
+			first := serve(t, r, "x", nil)
+			etag := first.Header().Get("ETag")
+			if first.Code != http.StatusOK || etag == "" {
+				t.Fatalf("first response: got %d with ETag %q", first.Code, etag)
+			}
+			if got, want := first.Body.String(), "<p>x</p>"; got != want {
+				t.Errorf("first response: got %q, want %q", got, want)
+			}
+
+			again := serve(t, r, "x", map[string]string{"If-None-Match": etag})
+			if again.Code != http.StatusNotModified || again.Body.Len() != 0 {
+				t.Errorf("revalidation: got %d with body %q, want 304 without body", again.Code, again.Body.String())
+			}
+
+			changed := serve(t, r, "y", map[string]string{"If-None-Match": etag})
+			if changed.Code != http.StatusOK || changed.Header().Get("ETag") == etag {
+				t.Errorf("changed data: got %d with ETag %q", changed.Code, changed.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestServeTemplateVariants(t *testing.T) {
+	r := newServeRenderer(t)
+	etag := serve(t, r, "x", map[string]string{"Accept": "text/html"}).Header().Get("ETag")
+
+	tests := []struct {
+		name     string
+		header   map[string]string
+		wantBody string
+	}{
+		{name: "locale", header: map[string]string{"X-Locale": "fr"}, wantBody: `<p lang="fr">x</p>`},
+		{name: "xhtml", header: map[string]string{"Accept": "application/xhtml+xml"}, wantBody: `<p xmlns="http://www.w3.org/1999/xhtml">x</p>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.header["If-None-Match"] = etag
+			rec := serve(t, r, "x", tt.header)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("got status %d, want 200", rec.Code)
+			}
+			if rec.Header().Get("ETag") == etag {
+				t.Error("variant has the same ETag as the default")
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("got %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+
+	if got := serve(t, r, "x", map[string]string{"Accept": "text/html", "If-None-Match": etag}).Header().Get("Vary"); got != "Accept" {
+		t.Errorf("Vary: got %q, want Accept", got)
+	}
+}
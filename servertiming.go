@@ -0,0 +1,87 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerTimingRenderer is a Renderer that reports the duration of renders to
+// an http.ResponseWriter in a Server-Timing header, which browser DevTools
+// show alongside the network timings of the response. Each render adds a
+// metric of the form
+//
+//	tplx;dur=<milliseconds>;desc="<name>"
+//
+// so that multiple renders in one response are listed individually. Since
+// headers cannot be changed once the body has been written, the output is
+// rendered into a buffer and written after the header has been added.
+//
+// Renders to other writers, such as the buffers of RenderResult or the
+// writers the render pipeline wraps responses in, are timed if the context
+// carries the response writer (see NewResponseContext), and passed through
+// untimed otherwise.
+type ServerTimingRenderer struct {
+	r Renderer
+}
+
+// NewServerTimingRenderer returns a ServerTimingRenderer wrapping r.
+func NewServerTimingRenderer(r Renderer) *ServerTimingRenderer {
+	return &ServerTimingRenderer{r: r}
+}
+
+// Render renders a named template using the wrapped renderer.
+func (st *ServerTimingRenderer) Render(w io.Writer, name string, data any, funcs template.FuncMap) error {
+	return st.RenderContext(context.Background(), w, name, data, funcs)
+}
+
+// RenderContext is like Render but carries a context through the render
+// pipeline of the wrapped renderer.
+func (st *ServerTimingRenderer) RenderContext(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+	return timeServer(ctx, w, name, func(w io.Writer) error {
+		return RenderContext(ctx, st.r, w, name, data, funcs)
+	})
+}
+
+// WithServerTiming adds a Server-Timing metric for each render to an
+// http.ResponseWriter, like a ServerTimingRenderer, but as part of the render
+// pipeline, so that it also covers the middlewares registered after it.
+func WithServerTiming() Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, func(next RenderHandler) RenderHandler {
+			return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+				return timeServer(ctx, w, name, func(w io.Writer) error {
+					return next(ctx, w, name, data, funcs)
+				})
+			}
+		})
+	}
+}
+
+// timeServer calls render and, if w is an http.ResponseWriter or ctx carries
+// one, adds its duration to the Server-Timing header before writing its
+// output to w.
+func timeServer(ctx context.Context, w io.Writer, name string, render func(w io.Writer) error) error {
+	rw, ok := responseWriter(ctx, w)
+	if !ok {
+		return render(w)
+	}
+
+	var buf bytes.Buffer
+	start := time.Now()
+	if err := render(&buf); err != nil {
+		return err
+	}
+	dur := time.Since(start)
+
+	desc := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(name)
+	ms := strconv.FormatFloat(float64(dur)/float64(time.Millisecond), 'f', 2, 64)
+	rw.Header().Add("Server-Timing", "tplx;dur="+ms+`;desc="`+desc+`"`)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,55 @@
+package tplx
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// ReloadOnSignal reloads all templates of r whenever the process receives sig,
+// giving operators an explicit way to trigger a reload, e.g. with kill -HUP.
+// If sig is nil, syscall.SIGHUP is used.
+//
+// The outcome of each reload is logged with slog.Default. ReloadOnSignal does
+// not block; reloads happen on a separate goroutine until stop is called.
+func ReloadOnSignal(r ReloadableRenderer, sig os.Signal) (stop func(), err error) {
+	if r == nil {
+		return nil, errors.New("renderer is nil")
+	}
+
+	if sig == nil {
+		sig = syscall.SIGHUP
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sig)
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case s := <-ch:
+				if err := r.Reload(); err != nil {
+					slog.Default().Error("cannot reload templates", "signal", s.String(), "error", err)
+				} else {
+					slog.Default().Info("reloaded templates", "signal", s.String())
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+
+	return stop, nil
+}
@@ -0,0 +1,120 @@
+package tplx
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// RenderTokenHeader is the HTTP header carrying the token of WithRenderSigning.
+const RenderTokenHeader = "X-Render-Token"
+
+// ErrInvalidRenderToken is returned by VerifyRenderToken for malformed tokens
+// and tokens with an invalid signature.
+var ErrInvalidRenderToken = errors.New("invalid render token")
+
+// RenderTokenClaims are the facts attested by a render token: the top-level
+// template rendered, the SHA-256 hash of the JSON encoding of its data, and
+// the time of the render.
+type RenderTokenClaims struct {
+	Template string    `json:"template"`
+	DataHash string    `json:"data_hash"`
+	Time     time.Time `json:"time"`
+}
+
+// WithRenderSigning attaches a token signed with HMAC-SHA256 using key to each
+// render, to prove which template was rendered with which data at which time.
+//
+// If the writer is an http.ResponseWriter, or the render context carries one
+// (see NewResponseContext) as it does for renders through RenderResult or the
+// HTTP integrations, the token is sent in the RenderTokenHeader header of that
+// response. Otherwise it is appended to the output of successful renders as an
+// HTML comment of the form <!-- render-token: TOKEN -->, which is also the
+// case when a handler renders into its own buffer without the response writer
+// in the context. Tokens are checked with VerifyRenderToken.
+func WithRenderSigning(key []byte) Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, func(next RenderHandler) RenderHandler {
+			return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+				token, err := signRenderToken(RenderTokenClaims{
+					Template: name,
+					DataHash: hashData(data),
+					Time:     time.Now().UTC(),
+				}, key)
+				if err != nil {
+					return err
+				}
+
+				rw, ok := responseWriter(ctx, w)
+				if ok {
+					rw.Header().Set(RenderTokenHeader, token)
+				}
+
+				if err := next(ctx, w, name, data, funcs); err != nil {
+					return err
+				}
+
+				if !ok {
+					_, err = fmt.Fprintf(w, "<!-- render-token: %s -->", token)
+				}
+				return err
+			}
+		})
+	}
+}
+
+func signRenderToken(claims RenderTokenClaims, key []byte) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifyRenderToken checks the signature of a token created by
+// WithRenderSigning with the same key.
+//
+// Returns the claims of the token, or an error wrapping ErrInvalidRenderToken
+// if the token is malformed or its signature does not match.
+func VerifyRenderToken(token string, key []byte) (RenderTokenClaims, error) {
+	enc := base64.RawURLEncoding
+
+	p, s, ok := strings.Cut(token, ".")
+	if !ok {
+		return RenderTokenClaims{}, fmt.Errorf("%w: missing signature", ErrInvalidRenderToken)
+	}
+
+	payload, err := enc.DecodeString(p)
+	if err != nil {
+		return RenderTokenClaims{}, fmt.Errorf("%w: %v", ErrInvalidRenderToken, err)
+	}
+	sig, err := enc.DecodeString(s)
+	if err != nil {
+		return RenderTokenClaims{}, fmt.Errorf("%w: %v", ErrInvalidRenderToken, err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return RenderTokenClaims{}, fmt.Errorf("%w: signature mismatch", ErrInvalidRenderToken)
+	}
+
+	var claims RenderTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return RenderTokenClaims{}, fmt.Errorf("%w: %v", ErrInvalidRenderToken, err)
+	}
+	return claims, nil
+}
@@ -0,0 +1,128 @@
+package tplx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// SitemapEntry describes a page listed in a sitemap.
+//
+// URL is the location of the page, resolved against the base URL passed to
+// RenderSitemap if it is relative. TemplateName is the top-level template
+// rendering the page, whose modification time becomes the last modification
+// of the entry. Priority is the priority of the page relative to the other
+// pages of the site between 0 and 1, where 0 omits it. Changefreq is one of
+// "always", "hourly", "daily", "weekly", "monthly", "yearly" and "never", or
+// empty.
+type SitemapEntry struct {
+	URL          string
+	TemplateName string
+	Priority     float64
+	Changefreq   string
+}
+
+// RegisterSitemapEntry lists entry in the sitemaps rendered from the renderer
+// by RenderSitemap, so that pages can be declared together with the renderer.
+// If entry.TemplateName is empty, it is set to name.
+func RegisterSitemapEntry(name string, entry SitemapEntry) Option {
+	return func(c *config) {
+		if entry.TemplateName == "" {
+			entry.TemplateName = name
+		}
+		if c.sitemap == nil {
+			c.sitemap = make(map[string]SitemapEntry)
+		}
+		c.sitemap[name] = entry
+	}
+}
+
+// SitemapEntries returns the entries registered with RegisterSitemapEntry,
+// sorted by the names they were registered with.
+func (r *renderer) SitemapEntries() []SitemapEntry {
+	entries := make([]SitemapEntry, 0, len(r.c.sitemap))
+	for _, name := range slices.Sorted(maps.Keys(r.c.sitemap)) {
+		entries = append(entries, r.c.sitemap[name])
+	}
+	return entries
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	Changefreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+// RenderSitemap renders an XML sitemap listing entries followed by the
+// entries registered with r using RegisterSitemapEntry. If r provides
+// LastModified, as renderers created by NewRenderer do, each entry naming a
+// template gets the modification time of its files as its last modification.
+//
+// Returns the sitemap, or an error if baseURL or the URL of an entry is
+// invalid, an entry has an invalid priority or change frequency, or the
+// modification time of a template cannot be determined.
+func RenderSitemap(r Renderer, entries []SitemapEntry, baseURL string) ([]byte, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+
+	if reg, ok := r.(interface{ SitemapEntries() []SitemapEntry }); ok {
+		entries = append(slices.Clip(entries), reg.SitemapEntries()...)
+	}
+	modder, _ := r.(interface {
+		LastModified(name string) (time.Time, error)
+	})
+
+	set := sitemapURLSet{URLs: make([]sitemapURL, 0, len(entries))}
+	for _, entry := range entries {
+		loc, err := url.Parse(entry.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL of sitemap entry: %w", err)
+		}
+
+		u := sitemapURL{Loc: base.ResolveReference(loc).String()}
+
+		switch entry.Changefreq {
+		case "", "always", "hourly", "daily", "weekly", "monthly", "yearly", "never":
+			u.Changefreq = entry.Changefreq
+		default:
+			return nil, fmt.Errorf("sitemap entry %q has invalid change frequency %q", entry.URL, entry.Changefreq)
+		}
+
+		if entry.Priority < 0 || entry.Priority > 1 {
+			return nil, fmt.Errorf("sitemap entry %q has priority %v outside of [0, 1]", entry.URL, entry.Priority)
+		}
+		if entry.Priority > 0 {
+			u.Priority = strconv.FormatFloat(entry.Priority, 'f', -1, 64)
+		}
+
+		if entry.TemplateName != "" && modder != nil {
+			t, err := modder.LastModified(entry.TemplateName)
+			if err != nil {
+				return nil, fmt.Errorf("sitemap entry %q: %w", entry.URL, err)
+			}
+			if !t.IsZero() {
+				u.LastMod = t.UTC().Format(time.RFC3339)
+			}
+		}
+
+		set.URLs = append(set.URLs, u)
+	}
+
+	out, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
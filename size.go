@@ -0,0 +1,115 @@
+package tplx
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"math"
+	"slices"
+	"sync"
+)
+
+// SizeRecorder receives the number of bytes written by each render.
+type SizeRecorder interface {
+	RecordSize(name string, bytes int)
+}
+
+// WithSizeMetrics reports the size of the rendered output of every render of
+// the top-level template name to recorder. The size is recorded for failed
+// renders as well and counts the bytes that have reached the writer.
+func WithSizeMetrics(recorder SizeRecorder) Option {
+	return func(c *config) {
+		c.middlewares = append(c.middlewares, func(next RenderHandler) RenderHandler {
+			return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+				cw := &countingWriter{w: w}
+				err := next(ctx, cw, name, data, funcs)
+				recorder.RecordSize(name, cw.n)
+				return err
+			}
+		})
+	}
+}
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += n
+	return n, err
+}
+
+// HistogramSizeRecorder is a SizeRecorder that keeps all recorded sizes in
+// memory for in-process inspection. The zero value is ready to use.
+type HistogramSizeRecorder struct {
+	m sync.Map // name -> *sizeSeries
+}
+
+type sizeSeries struct {
+	mu    sync.Mutex
+	sizes []int
+}
+
+// RecordSize records a render of name that produced the given number of bytes.
+func (h *HistogramSizeRecorder) RecordSize(name string, bytes int) {
+	v, _ := h.m.LoadOrStore(name, &sizeSeries{})
+	s := v.(*sizeSeries)
+	s.mu.Lock()
+	s.sizes = append(s.sizes, bytes)
+	s.mu.Unlock()
+}
+
+// Sizes returns a copy of all sizes recorded for name, in recording order.
+func (h *HistogramSizeRecorder) Sizes(name string) []int {
+	v, ok := h.m.Load(name)
+	if !ok {
+		return nil
+	}
+	s := v.(*sizeSeries)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.sizes)
+}
+
+// Mean returns the average size recorded for name, or 0 if there are none.
+func (h *HistogramSizeRecorder) Mean(name string) float64 {
+	sizes := h.Sizes(name)
+	if len(sizes) == 0 {
+		return 0
+	}
+
+	sum := 0
+	for _, n := range sizes {
+		sum += n
+	}
+	return float64(sum) / float64(len(sizes))
+}
+
+// Percentile returns the p-th percentile, with p between 0 and 100, of the
+// sizes recorded for name using the nearest-rank method, or 0 if there are
+// none.
+func (h *HistogramSizeRecorder) Percentile(name string, p float64) int {
+	sizes := h.Sizes(name)
+	if len(sizes) == 0 {
+		return 0
+	}
+	slices.Sort(sizes)
+
+	rank := int(math.Ceil(p / 100 * float64(len(sizes))))
+	rank = min(max(rank, 1), len(sizes))
+	return sizes[rank-1]
+}
+
+// Names returns the names of all templates with recorded sizes, sorted.
+func (h *HistogramSizeRecorder) Names() []string {
+	var names []string
+	h.m.Range(func(k, _ any) bool {
+		names = append(names, k.(string))
+		return true
+	})
+	slices.Sort(names)
+	return names
+}
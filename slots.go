@@ -0,0 +1,74 @@
+package tplx
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"unicode"
+)
+
+// ErrInvalidSlot is returned by SlotFuncMap for slot names that cannot be
+// template function names.
+var ErrInvalidSlot = errors.New("invalid slot name")
+
+// SlotFuncMap returns template functions that give templates access to HTML
+// supplied by the calling code, such as a sidebar that is not defined by any
+// template.
+//
+// The returned map contains a slot function, which returns the value of the
+// named slot or an empty string for unknown slots, and one function per slot
+// name returning that slot's value:
+//
+//	{{slot "sidebar"}}
+//	{{sidebar}}
+//
+// The values are inserted as they are, without escaping, so they must be
+// trusted HTML.
+//
+// Slots are meant to be filled per render by passing the map as funcs to
+// Render. As template functions must exist when templates are parsed, the
+// functions also have to be registered as global functions beforehand, which
+// is done by passing a SlotFuncMap with placeholder values to NewRenderer:
+//
+//	global, err := tplx.SlotFuncMap(map[string]template.HTML{"sidebar": ""})
+//	// ...
+//	r, err := tplx.NewRenderer(fsys, spec, global)
+//	// ...
+//	slots, err := tplx.SlotFuncMap(map[string]template.HTML{"sidebar": sidebar})
+//	// ...
+//	err = r.Render(w, "page", data, slots)
+//
+// Returns the functions, or an error wrapping ErrInvalidSlot if a slot name
+// is not a valid template function name or is "slot" itself.
+func SlotFuncMap(slots map[string]template.HTML) (template.FuncMap, error) {
+	funcs := make(template.FuncMap, len(slots)+1)
+	for name, html := range slots {
+		if name == "slot" || !isFuncName(name) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSlot, name)
+		}
+		funcs[name] = func() template.HTML {
+			return html
+		}
+	}
+	funcs["slot"] = func(name string) template.HTML {
+		return slots[name]
+	}
+	return funcs, nil
+}
+
+// isFuncName reports whether name is accepted by template.Funcs: a letter or
+// underscore followed by letters, digits and underscores.
+func isFuncName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && unicode.IsDigit(r):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package tplx
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSlotFuncMap(t *testing.T) {
+	global, err := SlotFuncMap(map[string]template.HTML{"sidebar": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{"page.html": {Data: []byte(`{{sidebar}}|{{slot "sidebar"}}|{{slot "missing"}}`)}}
+	r, err := NewRenderer(fsys, Spec{"page": {{Name: "page", Path: "page.html"}}}, global)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slots, err := SlotFuncMap(map[string]template.HTML{"sidebar": "<nav></nav>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := r.Render(&buf, "page", nil, slots); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "<nav></nav>|<nav></nav>|"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSlotFuncMapInvalidNames(t *testing.T) {
+	for _, name := range []string{"", "side-bar", "1st", "slot", "a b"} {
+		if _, err := SlotFuncMap(map[string]template.HTML{name: ""}); !errors.Is(err, ErrInvalidSlot) {
+			t.Errorf("%q: got %v, want ErrInvalidSlot", name, err)
+		}
+	}
+}
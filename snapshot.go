@@ -0,0 +1,50 @@
+package tplx
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RendererSnapshot holds the templates of a renderer at a point in time.
+type RendererSnapshot struct {
+	m map[string]*entry
+}
+
+// Snapshot captures the current templates of r, so that they can later be
+// restored with Restore. This is mostly useful for isolating tests that modify
+// a shared renderer:
+//
+//	snap, err := tplx.Snapshot(r)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	t.Cleanup(func() { tplx.Restore(r, snap) })
+//
+// Parsed templates are never modified in place, since every change replaces
+// them with new clones, so taking a snapshot does not copy any templates.
+//
+// Returns an error wrapping errors.ErrUnsupported if r was not created by this
+// package.
+func Snapshot(r Renderer) (RendererSnapshot, error) {
+	rr, ok := r.(*renderer)
+	if !ok {
+		return RendererSnapshot{}, fmt.Errorf("cannot snapshot %T: %w", r, errors.ErrUnsupported)
+	}
+	return RendererSnapshot{m: *rr.m.Load()}, nil
+}
+
+// Restore atomically replaces the templates of r with those captured in snap.
+//
+// Returns an error wrapping errors.ErrUnsupported if r was not created by this
+// package.
+func Restore(r Renderer, snap RendererSnapshot) error {
+	rr, ok := r.(*renderer)
+	if !ok {
+		return fmt.Errorf("cannot restore %T: %w", r, errors.ErrUnsupported)
+	}
+
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	rr.m.Store(&snap.m)
+	return nil
+}
@@ -0,0 +1,147 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net"
+	"os"
+)
+
+// socketRequest is a render request sent to a socket renderer.
+type socketRequest struct {
+	Name string `json:"name"`
+	Data any    `json:"data"`
+}
+
+// socketResponse is the reply of a socket renderer to a socketRequest.
+type socketResponse struct {
+	Output  []byte `json:"output,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Unknown bool   `json:"unknown,omitempty"`
+}
+
+// NewSocketRenderer serves renders of r on a unix domain socket at
+// socketPath, for architectures that render templates in a dedicated process.
+// A stale socket file at socketPath is removed first. NewSocketRenderer blocks
+// like http.ListenAndServe; use ServeSocket to control the listener.
+//
+// Returns an error if the socket cannot be created or accepting connections
+// fails.
+func NewSocketRenderer(socketPath string, r Renderer) error {
+	if err := os.Remove(socketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cannot remove stale socket: %w", err)
+	}
+
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	return ServeSocket(l, r)
+}
+
+// ServeSocket accepts connections on l and serves renders of r on them until
+// l is closed. Each connection carries a sequence of JSON-encoded requests,
+// each holding the template name and data, answered by JSON-encoded
+// responses holding the output or error. Since functions cannot be encoded,
+// requests carry no per-call funcs. See SocketRendererClient for the client.
+//
+// Returns the error that caused accepting to fail.
+func ServeSocket(l net.Listener, r Renderer) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go serveSocketConn(conn, r)
+	}
+}
+
+func serveSocketConn(conn net.Conn, r Renderer) {
+	defer conn.Close()
+
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+	for {
+		var req socketRequest
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+
+		var resp socketResponse
+		var buf bytes.Buffer
+		if err := r.Render(&buf, req.Name, req.Data, nil); err != nil {
+			resp.Error = err.Error()
+			resp.Unknown = errors.Is(err, ErrUnknownTemplate)
+		} else {
+			resp.Output = buf.Bytes()
+		}
+
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// SocketRendererClient returns a Renderer that sends renders to a socket
+// renderer listening at socketPath (see NewSocketRenderer). Each render uses
+// a new connection.
+//
+// The data must be encodable as JSON and is decoded by the server into plain
+// maps, slices and values, so templates see no methods of the original data.
+// Renders with funcs fail, since functions cannot be sent. Template errors of
+// the server are returned as plain errors, except that unknown templates are
+// reported as ErrUnknownTemplate.
+func SocketRendererClient(socketPath string) Renderer {
+	return socketClient{path: socketPath}
+}
+
+type socketClient struct {
+	path string
+}
+
+func (sc socketClient) Render(w io.Writer, name string, data any, funcs template.FuncMap) error {
+	return sc.RenderContext(context.Background(), w, name, data, funcs)
+}
+
+func (sc socketClient) RenderContext(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+	if len(funcs) > 0 {
+		return errors.New("cannot send template functions to socket renderer")
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", sc.path)
+	if err != nil {
+		return fmt.Errorf("cannot connect to socket renderer: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if err := json.NewEncoder(conn).Encode(socketRequest{Name: name, Data: data}); err != nil {
+		return fmt.Errorf("cannot send render request: %w", err)
+	}
+
+	var resp socketResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return fmt.Errorf("cannot read render response: %w", err)
+	}
+
+	switch {
+	case resp.Unknown:
+		return ErrUnknownTemplate
+	case resp.Error != "":
+		return errors.New(resp.Error)
+	}
+
+	_, err = w.Write(resp.Output)
+	return err
+}
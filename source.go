@@ -0,0 +1,28 @@
+package tplx
+
+// WithSourceRetention makes the renderer keep the text of every template file
+// after parsing, so that it can be retrieved with TemplateSource. It is off by
+// default to save memory.
+func WithSourceRetention() Option {
+	return func(c *config) {
+		c.retainSource = true
+	}
+}
+
+// TemplateSource returns the text that the named fragment within a top-level
+// template was parsed from, after any preprocessing such as comment stripping.
+//
+// Returns ErrUnknownTemplate if either name is not found or source retention
+// is not enabled via WithSourceRetention.
+func (r *renderer) TemplateSource(name, fragment string) (string, error) {
+	e, ok := r.lookup(name)
+	if !ok {
+		return "", ErrUnknownTemplate
+	}
+
+	text, ok := e.sources[fragment]
+	if !ok {
+		return "", ErrUnknownTemplate
+	}
+	return text, nil
+}
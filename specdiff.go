@@ -0,0 +1,79 @@
+package tplx
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// SpecDiff describes how the top-level templates of two specs differ. Added
+// and Removed list the templates present only in the new or the old spec, and
+// Modified the templates present in both whose fragments differ. All lists
+// are sorted.
+type SpecDiff struct {
+	Added    []string
+	Removed  []string
+	Modified []string
+}
+
+// DiffSpecs compares the specs old and new. Fragments are compared by their
+// name, path, format, encoding, extended template, comment stripping,
+// annotations and the names of their functions, since functions themselves
+// cannot be compared. The order of fragments matters, as it determines which
+// blocks win.
+func DiffSpecs(old, new Spec) SpecDiff {
+	var d SpecDiff
+	for _, name := range slices.Sorted(maps.Keys(new)) {
+		metas, ok := old[name]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, name)
+		case !slices.EqualFunc(metas, new[name], metaEqual):
+			d.Modified = append(d.Modified, name)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(old)) {
+		if _, ok := new[name]; !ok {
+			d.Removed = append(d.Removed, name)
+		}
+	}
+	return d
+}
+
+// metaEqual reports whether a and b describe the same fragment.
+func metaEqual(a, b Meta) bool {
+	return a.Name == b.Name &&
+		a.Path == b.Path &&
+		a.Format == b.Format &&
+		a.Encoding == b.Encoding &&
+		a.Extends == b.Extends &&
+		a.StripComments == b.StripComments &&
+		maps.Equal(a.Annotations, b.Annotations) &&
+		slices.Equal(slices.Sorted(maps.Keys(a.Funcs)), slices.Sorted(maps.Keys(b.Funcs)))
+}
+
+// Empty reports whether the specs compared by d are equal.
+func (d SpecDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// String returns a human-readable summary of d, with one line per template
+// prefixed by +, - or ~ for added, removed and modified templates.
+func (d SpecDiff) String() string {
+	if d.Empty() {
+		return "no changes"
+	}
+
+	var b strings.Builder
+	for _, group := range []struct {
+		prefix string
+		names  []string
+	}{{"+", d.Added}, {"-", d.Removed}, {"~", d.Modified}} {
+		for _, name := range group.names {
+			fmt.Fprintf(&b, "%s %s\n", group.prefix, name)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,109 @@
+package tplx
+
+import (
+	"encoding/json"
+	"html/template"
+	"maps"
+	"slices"
+)
+
+// jsonMeta is the serialized form of Meta. Funcs cannot be serialized and are
+// omitted.
+type jsonMeta struct {
+	Name          string            `json:"name,omitempty"`
+	Path          string            `json:"path,omitempty"`
+	StripComments bool              `json:"stripComments,omitempty"`
+	Extends       string            `json:"extends,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+	Format        string            `json:"format,omitempty"`
+	Encoding      string            `json:"encoding,omitempty"`
+}
+
+// MarshalSpec serializes spec as JSON, which allows specs to be generated or
+// consumed by tools written in other languages.
+//
+// The result is an object mapping top-level template names to arrays of
+// fragments with the keys "name", "path", "stripComments", "extends",
+// "annotations", "format" and "encoding". Since functions cannot be
+// serialized, Meta.Funcs is omitted; see SpecWithFuncs for re-attaching
+// functions.
+func MarshalSpec(spec Spec) ([]byte, error) {
+	out := make(map[string][]jsonMeta, len(spec))
+	for name, metas := range spec {
+		jm := make([]jsonMeta, len(metas))
+		for i, meta := range metas {
+			jm[i] = jsonMeta{
+				Name:          meta.Name,
+				Path:          meta.Path,
+				StripComments: meta.StripComments,
+				Extends:       meta.Extends,
+				Annotations:   meta.Annotations,
+				Format:        meta.Format,
+				Encoding:      meta.Encoding,
+			}
+		}
+		out[name] = jm
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalSpec parses a Spec serialized by MarshalSpec. The returned spec has
+// no functions attached.
+func UnmarshalSpec(data []byte) (Spec, error) {
+	var in map[string][]jsonMeta
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	spec := make(Spec, len(in))
+	for name, jm := range in {
+		metas := make([]Meta, len(jm))
+		for i, m := range jm {
+			metas[i] = Meta{
+				Name:          m.Name,
+				Path:          m.Path,
+				StripComments: m.StripComments,
+				Extends:       m.Extends,
+				Annotations:   m.Annotations,
+				Format:        m.Format,
+				Encoding:      m.Encoding,
+			}
+		}
+		spec[name] = metas
+	}
+	return spec, nil
+}
+
+// SpecWithFuncs attaches functions to a Spec that was deserialized without
+// them.
+//
+// Funcs maps fragment names to the functions of every Meta with that name,
+// regardless of the top-level template it belongs to.
+type SpecWithFuncs struct {
+	Spec  Spec
+	Funcs map[string]template.FuncMap
+}
+
+// Resolve returns a copy of the spec with the functions attached. Functions
+// already present on a Meta are kept unless Funcs defines the same name.
+func (s SpecWithFuncs) Resolve() Spec {
+	spec := make(Spec, len(s.Spec))
+	for name, metas := range s.Spec {
+		metas = slices.Clone(metas)
+		for i, meta := range metas {
+			funcs, ok := s.Funcs[meta.Name]
+			if !ok {
+				continue
+			}
+
+			merged := maps.Clone(meta.Funcs)
+			if merged == nil {
+				merged = make(template.FuncMap, len(funcs))
+			}
+			maps.Copy(merged, funcs)
+			metas[i].Funcs = merged
+		}
+		spec[name] = metas
+	}
+	return spec
+}
@@ -0,0 +1,54 @@
+package tplx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+)
+
+// ErrInvalidStep is returned by StepRenderer.RenderStep for steps outside of
+// the wizard.
+var ErrInvalidStep = errors.New("invalid wizard step")
+
+// StepRenderer renders the steps of a wizard, such as a multi-step form,
+// where each step is a top-level template and the current step is kept by the
+// application, e.g. in a hidden form field or the session.
+type StepRenderer struct {
+	r     Renderer
+	steps []string
+}
+
+// NewStepRenderer creates a StepRenderer rendering the templates named by
+// steps with r, where steps[0] is the first step.
+func NewStepRenderer(r Renderer, steps ...string) *StepRenderer {
+	return &StepRenderer{r: r, steps: slices.Clone(steps)}
+}
+
+// RenderStep renders the template of the given step to w.
+//
+// Returns ErrInvalidStep if step is outside of the wizard, or an error if the
+// template cannot be rendered.
+func (sr *StepRenderer) RenderStep(ctx context.Context, w io.Writer, step int, data any, funcs template.FuncMap) error {
+	if step < 0 || step >= len(sr.steps) {
+		return fmt.Errorf("%w: %d of %d", ErrInvalidStep, step, len(sr.steps))
+	}
+	return RenderContext(ctx, sr.r, w, sr.steps[step], data, funcs)
+}
+
+// NextStep returns the step following current if valid reports that the
+// input of current was accepted, and current otherwise so that it is shown
+// again. The last step is never advanced beyond.
+func (sr *StepRenderer) NextStep(current int, valid bool) int {
+	if !valid {
+		return current
+	}
+	return min(current+1, len(sr.steps)-1)
+}
+
+// StepCount returns the number of steps.
+func (sr *StepRenderer) StepCount() int {
+	return len(sr.steps)
+}
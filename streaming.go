@@ -0,0 +1,51 @@
+package tplx
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"net/http"
+)
+
+// FlushWriter is a writer that can flush buffered data to its client, such as
+// an http.ResponseWriter supporting http.Flusher.
+type FlushWriter interface {
+	io.Writer
+	http.Flusher
+}
+
+// StreamingRender renders the named template using r to w, flushing w
+// whenever at least chunkSize bytes have been written since the last flush
+// and once more at the end. This gets output to the client while the rest of
+// the template is still rendering, improving time to first byte for slow
+// templates and enabling server-sent events. If chunkSize is 0 or less, w is
+// flushed after every write.
+//
+// Flushes happen wherever the byte count is reached, so the client may
+// receive incomplete HTML, e.g. half of an element. Use this only for
+// templates whose output is meaningful at arbitrary points, or size chunks
+// so that flushes coincide with block boundaries. Output that has been
+// flushed cannot be taken back if the render fails.
+func StreamingRender(ctx context.Context, r Renderer, w FlushWriter, name string, data any, funcs template.FuncMap, chunkSize int) error {
+	cw := &chunkWriter{w: w, size: chunkSize}
+	err := RenderContext(ctx, r, cw, name, data, funcs)
+	w.Flush()
+	return err
+}
+
+// chunkWriter flushes w after every size bytes.
+type chunkWriter struct {
+	w       FlushWriter
+	size    int
+	pending int
+}
+
+func (cw *chunkWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.pending += n
+	if cw.pending >= cw.size {
+		cw.w.Flush()
+		cw.pending = 0
+	}
+	return n, err
+}
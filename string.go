@@ -0,0 +1,21 @@
+package tplx
+
+import (
+	"html/template"
+	"strings"
+)
+
+// RenderString renders a named template using r and returns the output as a
+// string.
+//
+// The output is collected in a strings.Builder, which hands out its contents
+// without the extra copy that converting a bytes.Buffer to a string requires.
+//
+// Returns the rendered output or an error if the template cannot be rendered.
+func RenderString(r Renderer, name string, data any, funcs template.FuncMap) (string, error) {
+	var sb strings.Builder
+	if err := r.Render(&sb, name, data, funcs); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
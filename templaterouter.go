@@ -0,0 +1,116 @@
+package tplx
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// RouteSpec describes the template served for a route by TemplateRouter.
+//
+// TemplateName is the top-level template to render. DataFunc is the name of a
+// data function registered with RegisterDataFunc that computes the data for
+// the template; if empty, the template is rendered with nil data.
+type RouteSpec struct {
+	TemplateName string
+	DataFunc     string
+}
+
+var (
+	dataFuncsMu sync.RWMutex
+	dataFuncs   = make(map[string]func(*http.Request) (any, error))
+)
+
+// RegisterDataFunc registers a data function under name for use in a
+// RouteSpec. Registering a function under an existing name replaces it.
+func RegisterDataFunc(name string, fn func(*http.Request) (any, error)) {
+	dataFuncsMu.Lock()
+	defer dataFuncsMu.Unlock()
+	dataFuncs[name] = fn
+}
+
+// TemplateRouter returns an http.Handler that maps URL patterns directly to
+// templates, for applications in which most pages need no handler of their
+// own.
+//
+// The keys of routes are patterns in the syntax of http.ServeMux, such as
+// "GET /posts/{id}", so data functions can use Request.PathValue. Data
+// functions are looked up on each request. If a data function is not
+// registered, fails, or the template cannot be rendered, an internal server
+// error is returned. Responses are sent as text/html unless the render has
+// set a Content-Type, e.g. through WithXHTMLFallback.
+//
+// TemplateRouter panics if a pattern is invalid or conflicts with another, as
+// http.ServeMux.Handle does; use NewTemplateRouter for routes that are not
+// fixed at compile time.
+func TemplateRouter(r Renderer, routes map[string]RouteSpec) http.Handler {
+	h, err := NewTemplateRouter(r, routes)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// NewTemplateRouter is like TemplateRouter but returns an error instead of
+// panicking.
+//
+// Returns an error naming the first pattern, in sorted order, that is invalid
+// or conflicts with a previous one.
+func NewTemplateRouter(r Renderer, routes map[string]RouteSpec) (http.Handler, error) {
+	mux := http.NewServeMux()
+	for _, pattern := range slices.Sorted(maps.Keys(routes)) {
+		if err := handleRoute(mux, pattern, routeHandler(r, routes[pattern])); err != nil {
+			return nil, err
+		}
+	}
+	return mux, nil
+}
+
+// handleRoute registers h for pattern on mux, turning the panic of
+// http.ServeMux.Handle for invalid and conflicting patterns into an error.
+func handleRoute(mux *http.ServeMux, pattern string, h http.Handler) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("invalid route %q: %v", pattern, p)
+		}
+	}()
+	mux.Handle(pattern, h)
+	return nil
+}
+
+func routeHandler(r Renderer, route RouteSpec) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var data any
+		if route.DataFunc != "" {
+			dataFuncsMu.RLock()
+			fn, ok := dataFuncs[route.DataFunc]
+			dataFuncsMu.RUnlock()
+			if !ok {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			var err error
+			data, err = fn(req)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		var buf bytes.Buffer
+		ctx := NewResponseContext(NewRequestContext(NewContext(req.Context(), r), req), w)
+		if err := RenderContext(ctx, r, &buf, route.TemplateName, data, nil); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		}
+		_, _ = buf.WriteTo(w)
+	})
+}
@@ -0,0 +1,46 @@
+package tplx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTemplateRouterContentType(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.html":  {Data: []byte(`<p>page</p>`)},
+		"page.xhtml": {Data: []byte(`<p xmlns="http://www.w3.org/1999/xhtml">page</p>`)},
+	}
+	r, err := NewRenderer(fsys, Spec{"page": {{Name: "page", Path: "page.html"}}}, nil,
+		WithXHTMLFallback(Spec{"page": {{Name: "page", Path: "page.xhtml"}}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := NewTemplateRouter(r, map[string]RouteSpec{"GET /": {TemplateName: "page"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "text/html", want: "text/html; charset=utf-8"},
+		{accept: "application/xhtml+xml", want: "application/xhtml+xml; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept", tt.accept)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.want {
+				t.Errorf("got Content-Type %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
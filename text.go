@@ -0,0 +1,91 @@
+package tplx
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"maps"
+	"text/template"
+)
+
+// textRenderer renders templates parsed with text/template.
+type textRenderer struct {
+	m map[string]*template.Template
+}
+
+// parseText parses all fragments of the top-level template name in spec with
+// text/template, using funcs as the base functions and the delimiters and
+// missingkey mode of c.
+func parseText(c *config, fsys fs.FS, spec Spec, name string, funcs template.FuncMap) (*template.Template, error) {
+	metas, err := resolveExtends(spec, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !containsFragment(spec[name], name) {
+		return nil, ErrInvalidSpec
+	}
+
+	funcs = maps.Clone(funcs)
+	if funcs == nil {
+		funcs = make(template.FuncMap)
+	}
+	for _, meta := range metas {
+		maps.Copy(funcs, meta.Funcs)
+	}
+
+	t := template.New(name).Delims(c.delims[0], c.delims[1]).Funcs(funcs)
+	if c.missingKey != "" {
+		t = t.Option("missingkey=" + c.missingKey)
+	}
+	for _, meta := range metas {
+		text, err := fs.ReadFile(fsys, meta.Path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read template file: %w", err)
+		}
+
+		text, err = decodeText(text, meta.Encoding, false)
+		if err != nil {
+			return nil, err
+		}
+
+		if meta.StripComments {
+			text = commentPattern.ReplaceAll(text, nil)
+		}
+
+		t, err = t.New(meta.Name).Parse(string(text))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return t.Lookup(name), nil
+}
+
+func (r textRenderer) Render(w io.Writer, name string, data any, funcs htmltemplate.FuncMap) error {
+	return r.RenderBlock(w, name, name, data, funcs)
+}
+
+// RenderBlock renders the sub-template block within the set of the top-level
+// template name.
+func (r textRenderer) RenderBlock(w io.Writer, name, block string, data any, funcs htmltemplate.FuncMap) error {
+	t, ok := r.m[name]
+	if !ok || t.Lookup(block) == nil {
+		return ErrUnknownTemplate
+	}
+
+	if len(funcs) > 0 {
+		var err error
+		t, err = t.Clone()
+		if err != nil {
+			return fmt.Errorf("cannot render template: %w", err)
+		}
+		t = t.Funcs(template.FuncMap(funcs))
+	}
+
+	if err := t.ExecuteTemplate(w, block, data); err != nil {
+		return fmt.Errorf("cannot render template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package tplx
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"time"
+)
+
+// WithRenderTimeout limits the duration of each render. The context passed
+// down the render pipeline gets the timeout as its deadline, and template
+// execution is aborted with the context error at the first write after the
+// deadline has passed. A timeout of 0 or less disables the limit.
+func WithRenderTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.renderTimeout = timeout
+	}
+}
+
+func timeoutRender(next RenderHandler, timeout time.Duration) RenderHandler {
+	return func(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return next(ctx, ctxWriter{ctx: ctx, w: w}, name, data, funcs)
+	}
+}
+
+// ctxWriter fails all writes once its context is done.
+type ctxWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (cw ctxWriter) Write(p []byte) (int, error) {
+	if err := cw.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cw.w.Write(p)
+}
@@ -0,0 +1,118 @@
+package tplx
+
+import (
+	"context"
+	"html/template"
+	"io"
+	"maps"
+	"sync"
+	"time"
+)
+
+// TimingReport holds the timing breakdown of a render by a TimedRenderer.
+//
+// TemplateName is the rendered top-level template and Total the duration of
+// the whole render. SubTemplateTimings maps the names of timed sections to the
+// accumulated time spent in them.
+type TimingReport struct {
+	TemplateName       string
+	Total              time.Duration
+	SubTemplateTimings map[string]time.Duration
+}
+
+type timingKey struct{}
+
+// NewTimingContext returns a copy of ctx with an empty TimingReport that a
+// TimedRenderer fills in when rendering with the returned context.
+func NewTimingContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, timingKey{}, &TimingReport{})
+}
+
+// TimingFromContext returns the TimingReport stored in ctx by
+// NewTimingContext, or nil if there is none.
+func TimingFromContext(ctx context.Context) *TimingReport {
+	report, _ := ctx.Value(timingKey{}).(*TimingReport)
+	return report
+}
+
+// TimingFuncMap returns the placeholder functions timingStart and timingEnd,
+// which must be registered as global functions for templates to mark timed
+// sections:
+//
+//	{{timingStart "header"}}{{template "header" .}}{{timingEnd "header"}}
+//
+// Both take a section name and return an empty string. Outside of a
+// TimedRenderer they do nothing.
+func TimingFuncMap() template.FuncMap {
+	return template.FuncMap{
+		"timingStart": func(string) string { return "" },
+		"timingEnd":   func(string) string { return "" },
+	}
+}
+
+// TimedRenderer is a Renderer that measures how long each render and each
+// timed section of a template takes. Since html/template offers no hook into
+// the execution of sub-templates, sections are marked in the templates with
+// the functions of TimingFuncMap, which the TimedRenderer replaces for each
+// render.
+//
+// The report is written to the TimingReport stored in the render context by
+// NewTimingContext after each render, so that it can be retrieved with
+// TimingFromContext for logging. Renders without such a context are not timed.
+type TimedRenderer struct {
+	r Renderer
+}
+
+// NewTimedRenderer returns a TimedRenderer wrapping r.
+func NewTimedRenderer(r Renderer) *TimedRenderer {
+	return &TimedRenderer{r: r}
+}
+
+// Render renders a named template using the wrapped renderer. Since it has no
+// context to report to, no timing is recorded.
+func (tr *TimedRenderer) Render(w io.Writer, name string, data any, funcs template.FuncMap) error {
+	return tr.r.Render(w, name, data, funcs)
+}
+
+// RenderContext renders a named template using the wrapped renderer and
+// records its timings in the TimingReport stored in ctx.
+func (tr *TimedRenderer) RenderContext(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error {
+	report := TimingFromContext(ctx)
+	if report == nil {
+		return RenderContext(ctx, tr.r, w, name, data, funcs)
+	}
+
+	var (
+		mu      sync.Mutex
+		starts  = make(map[string]time.Time)
+		timings = make(map[string]time.Duration)
+	)
+
+	timed := make(template.FuncMap, len(funcs)+2)
+	maps.Copy(timed, funcs)
+	timed["timingStart"] = func(section string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		starts[section] = time.Now()
+		return ""
+	}
+	timed["timingEnd"] = func(section string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		if start, ok := starts[section]; ok {
+			timings[section] += time.Since(start)
+			delete(starts, section)
+		}
+		return ""
+	}
+
+	start := time.Now()
+	err := RenderContext(ctx, tr.r, w, name, data, timed)
+
+	*report = TimingReport{
+		TemplateName:       name,
+		Total:              time.Since(start),
+		SubTemplateTimings: timings,
+	}
+	return err
+}
@@ -3,11 +3,19 @@
 package tplx
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"io/fs"
+	"maps"
+	"regexp"
+	"slices"
+	"sync"
+	"sync/atomic"
 )
 
 var (
@@ -16,6 +24,9 @@ var (
 
 	// ErrInvalidSpec is returned when the template renderer specification is invalid.
 	ErrInvalidSpec = errors.New("template renderer spec is invalid")
+
+	// ErrInvalidOption is returned when an option passed to NewRenderer is invalid.
+	ErrInvalidOption = errors.New("template renderer option is invalid")
 )
 
 // Renderer is an interface for rendering templates.
@@ -23,8 +34,99 @@ type Renderer interface {
 	Render(w io.Writer, name string, data any, funcs template.FuncMap) error
 }
 
+// ContextRenderer is implemented by renderers that can render templates with a
+// context.
+type ContextRenderer interface {
+	RenderContext(ctx context.Context, w io.Writer, name string, data any, funcs template.FuncMap) error
+}
+
+// RenderContext renders a named template using r with the given context.
+//
+// If r implements ContextRenderer, its RenderContext method is used. Otherwise
+// the context is dropped and r.Render is called.
+func RenderContext(ctx context.Context, r Renderer, w io.Writer, name string, data any, funcs template.FuncMap) error {
+	if cr, ok := r.(ContextRenderer); ok {
+		return cr.RenderContext(ctx, w, name, data, funcs)
+	}
+	return r.Render(w, name, data, funcs)
+}
+
+// PatchableRenderer is a Renderer whose sub-templates can be replaced at
+// runtime.
+type PatchableRenderer interface {
+	Renderer
+	PatchSubTemplate(topLevel, subName string, text string) error
+}
+
+// renderer keeps its templates in a copy-on-write map. Reads load the current
+// map without locking, while writers serialize on mu, copy the map, and swap
+// it in atomically.
 type renderer struct {
-	m map[string]*template.Template
+	mu     sync.Mutex
+	m      atomic.Pointer[map[string]*entry]
+	c      *config
+	fsys   fs.FS
+	spec   Spec
+	funcs  template.FuncMap
+	render RenderHandler
+	xhtml  *textRenderer
+}
+
+// lookup returns the entry for the top-level template name.
+func (r *renderer) lookup(name string) (*entry, bool) {
+	e, ok := (*r.m.Load())[name]
+	return e, ok
+}
+
+// update applies fn to a copy of the template map and publishes the copy if fn
+// succeeds. The caller must not hold mu.
+func (r *renderer) update(fn func(m map[string]*entry) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m := maps.Clone(*r.m.Load())
+	if err := fn(m); err != nil {
+		return err
+	}
+
+	r.m.Store(&m)
+	return nil
+}
+
+// entry holds a parsed top-level template. The base template is never
+// executed so that it can still be cloned, while exec is the copy used for
+// rendering.
+type entry struct {
+	base        *template.Template
+	exec        *template.Template
+	sources     map[string]string
+	funcs       []string
+	annotations map[string]string
+	etag        string
+	ctxFuncs    template.FuncMap
+}
+
+// instance returns a template for executing e with the additional functions
+// funcs. Since functions are shared by all executions of a template, a fresh
+// clone is made whenever funcs is not empty.
+func (e *entry) instance(funcs template.FuncMap) (*template.Template, error) {
+	if len(funcs) == 0 {
+		return e.exec, nil
+	}
+
+	t, err := e.base.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return t.Funcs(funcs), nil
+}
+
+func newEntry(base *template.Template) (*entry, error) {
+	exec, err := base.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return &entry{base: base, exec: exec}, nil
 }
 
 // Spec describes the structure of all templates managed by the renderer.
@@ -38,59 +140,200 @@ type Spec map[string][]Meta
 //
 // Name specifies the name of the template fragment. Path specifies the path to
 // the template file in the file system. Funcs provides template-specific
-// functions.
+// functions. StripComments removes all HTML comments from the file content
+// before it is parsed.
+//
+// Alternatively, Extends names another top-level template in the Spec whose
+// fragments and functions are inherited. A Meta with Extends set does not
+// refer to a file of its own and all other fields are ignored. A template may
+// extend several templates, which are merged in the order described by
+// ResolveInheritanceOrder.
+//
+// Annotations holds arbitrary metadata for tooling and middleware, such as
+// required permissions or a cache TTL. It is not used by the renderer itself
+// (see Annotations).
+//
+// Format is only used by NewEmailRenderer and marks a fragment as part of the
+// "html" or the "text" version of an email.
+//
+// Encoding names the character encoding of the file, such as "windows-1252",
+// using the names of the WHATWG Encoding Standard. The file is converted to
+// UTF-8 in memory before it is parsed. By default files are expected to be
+// UTF-8 (but see WithCharsetDetection).
 type Meta struct {
-	Name  string
-	Path  string
-	Funcs template.FuncMap
+	Name          string
+	Path          string
+	Funcs         template.FuncMap
+	StripComments bool
+	Extends       string
+	Annotations   map[string]string
+	Format        string
+	Encoding      string
 }
 
+var commentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+
 // NewRenderer creates a new Renderer instance from a file system, specification,
 // and global function map.
 //
 // The fsys parameter specifies the file system from which template files are
 // loaded. The spec parameter defines the structure of the templates, mapping
 // top-level template names to their fragments. The funcs parameter provides
-// global template functions.
+// global template functions, which take precedence over functions registered
+// by options. The opts parameter configures optional behavior.
 //
 // Returns a Renderer instance or an error if the templates cannot be initialized
-// according to the specification.
-func NewRenderer(fsys fs.FS, spec Spec, funcs template.FuncMap) (Renderer, error) {
-	r := renderer{
-		m: make(map[string]*template.Template, len(spec)),
+// according to the specification. All templates are parsed even if some of them
+// fail, in which case the error is a MultiError holding a ParseError for each
+// failed template. Invalid options yield an error wrapping ErrInvalidOption.
+func NewRenderer(fsys fs.FS, spec Spec, funcs template.FuncMap, opts ...Option) (Renderer, error) {
+	if err := ValidateSpec(spec); err != nil {
+		return nil, err
 	}
 
-	for name, metas := range spec {
-		inc := false
+	c := newConfig(opts)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.integrity != nil {
+		if err := VerifySpec(fsys, spec, c.integrity); err != nil {
+			return nil, err
+		}
+	}
 
-		t := template.New(name).Funcs(funcs)
+	if c.funcs != nil {
+		merged := maps.Clone(c.funcs)
+		maps.Copy(merged, funcs)
+		funcs = merged
+	}
 
-		for _, meta := range metas {
-			if meta.Name == name {
-				inc = true
-			}
+	r := &renderer{c: c, fsys: fsys, spec: maps.Clone(spec), funcs: funcs}
+	r.render = c.builtins(c.chain(r.execute))
 
-			text, err := fs.ReadFile(fsys, meta.Path)
-			if err != nil {
-				return nil, fmt.Errorf("unable to read template file: %w", err)
-			}
+	m := make(map[string]*entry, len(spec))
+	var errs MultiError
+	for _, name := range slices.Sorted(maps.Keys(spec)) {
+		e, err := c.parseEntry(fsys, spec, name, funcs)
+		if err != nil {
+			errs = append(errs, asParseError(name, err))
+			continue
+		}
 
-			t = t.New(meta.Name).Funcs(meta.Funcs)
+		m[name] = e
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	r.m.Store(&m)
 
-			t, err = t.Parse(string(text))
-			if err != nil {
-				return nil, err
-			}
+	if c.xhtmlSpec != nil {
+		xhtml, err := parseXHTML(c, fsys, c.xhtmlSpec, funcs)
+		if err != nil {
+			return nil, err
 		}
+		r.xhtml = xhtml
+	}
+
+	return r, nil
+}
+
+// parseEntry parses all fragments of the top-level template name in spec.
+func (c *config) parseEntry(fsys fs.FS, spec Spec, name string, funcs template.FuncMap) (*entry, error) {
+	if !containsFragment(spec[name], name) {
+		return nil, ErrInvalidSpec
+	}
 
-		if !inc {
-			return nil, ErrInvalidSpec
+	metas, err := resolveExtends(spec, name)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(template.FuncMap, len(funcs))
+	maps.Copy(merged, funcs)
+	for _, meta := range metas {
+		maps.Copy(merged, meta.Funcs)
+	}
+
+	fragments := make([]FragmentText, 0, len(metas))
+	for _, meta := range metas {
+		text, err := fs.ReadFile(fsys, meta.Path)
+		if err != nil {
+			return nil, ParseError{Template: name, Fragment: meta.Name, Err: fmt.Errorf("unable to read template file: %w", err)}
+		}
+
+		text, err = decodeText(text, meta.Encoding, c.charsetDetection)
+		if err != nil {
+			return nil, ParseError{Template: name, Fragment: meta.Name, Err: err}
 		}
 
-		r.m[name] = t
+		if meta.StripComments {
+			text = commentPattern.ReplaceAll(text, nil)
+		}
+
+		fragments = append(fragments, FragmentText{Name: meta.Name, Text: string(text)})
 	}
 
-	return r, nil
+	e, err := c.compile(name, fragments, merged)
+	if err != nil {
+		return nil, err
+	}
+	e.annotations = mergeAnnotations(metas)
+
+	return e, nil
+}
+
+// compile parses the fragments of the top-level template name with the given
+// functions.
+func (c *config) compile(name string, fragments []FragmentText, funcs template.FuncMap) (*entry, error) {
+	funcs, err := c.mockFuncs(funcs)
+	if err != nil {
+		return nil, err
+	}
+	funcs, err = c.limitFuncs(funcs)
+	if err != nil {
+		return nil, err
+	}
+
+	t := template.New(name).Delims(c.delims[0], c.delims[1]).Funcs(funcs)
+	if c.missingKey != "" {
+		t = t.Option("missingkey=" + c.missingKey)
+	}
+
+	var sources map[string]string
+	if c.retainSource {
+		sources = make(map[string]string, len(fragments))
+	}
+	h := sha256.New()
+
+	for _, f := range fragments {
+		text := []byte(f.Text)
+		for _, transform := range c.transforms {
+			text = transform(text)
+		}
+
+		t, err = t.New(f.Name).Parse(string(text))
+		if err != nil {
+			return nil, ParseError{Template: name, Fragment: f.Name, Err: err}
+		}
+
+		if sources != nil {
+			sources[f.Name] = string(text)
+		}
+		fmt.Fprintf(h, "%q %q\n", f.Name, text)
+	}
+
+	e, err := newEntry(t.Lookup(name))
+	if err != nil {
+		return nil, err
+	}
+	e.sources = sources
+	e.funcs = slices.Sorted(maps.Keys(funcs))
+	if c.contextInjection {
+		e.ctxFuncs = contextFuncs(funcs)
+	}
+	e.etag = hex.EncodeToString(h.Sum(nil)[:16])
+
+	return e, nil
 }
 
 // Render writes the rendered output of a named template to the provided writer.
@@ -101,14 +344,127 @@ func NewRenderer(fsys fs.FS, spec Spec, funcs template.FuncMap) (Renderer, error
 // parameter provides additional template functions.
 //
 // Returns an error if the template cannot be rendered or does not exist.
-func (r renderer) Render(wr io.Writer, name string, data any, funcs template.FuncMap) error {
-	t, ok := r.m[name]
-	if !ok {
+func (r *renderer) Render(wr io.Writer, name string, data any, funcs template.FuncMap) error {
+	return r.RenderContext(context.Background(), wr, name, data, funcs)
+}
+
+// RenderContext is like Render but carries a context through the render
+// pipeline.
+func (r *renderer) RenderContext(ctx context.Context, wr io.Writer, name string, data any, funcs template.FuncMap) error {
+	if blockFromContext(ctx) != "" {
+		// Renders started from a block, such as includes, render whole
+		// templates.
+		ctx = context.WithValue(ctx, blockKey{}, "")
+	}
+	name = r.localize(ctx, name)
+	ctx = r.negotiateXHTML(ctx, wr, name)
+	return r.render(ctx, wr, name, data, funcs)
+}
+
+// execute is the final step of the render pipeline.
+func (r *renderer) execute(ctx context.Context, wr io.Writer, name string, data any, funcs template.FuncMap) error {
+	block := name
+	if b := blockFromContext(ctx); b != "" {
+		block = b
+	}
+
+	if xhtmlFromContext(ctx) {
+		return r.xhtml.RenderBlock(wr, name, block, data, funcs)
+	}
+
+	if _, ok := r.spec[name]; ok && r.c.hotReload {
+		if err := r.Reload(name); err != nil {
+			return err
+		}
+	}
+
+	e, ok := r.lookup(name)
+	if !ok || e.base.Lookup(block) == nil {
 		return ErrUnknownTemplate
 	}
-	err := t.ExecuteTemplate(wr, name, data)
+	return r.executeTemplate(ctx, e, wr, name, block, data, funcs)
+}
+
+// executeTemplate executes the template named block within the set of the
+// top-level template name.
+func (r *renderer) executeTemplate(ctx context.Context, e *entry, wr io.Writer, name, block string, data any, funcs template.FuncMap) error {
+	if r.c.crossIncludes {
+		funcs = r.includeFuncs(ctx, funcs)
+	}
+	funcs, err := r.c.mockFuncs(funcs)
+	if err != nil {
+		return err
+	}
+	funcs, err = r.c.limitFuncs(funcs)
+	if err != nil {
+		return err
+	}
+	if r.c.contextInjection {
+		funcs = injectContext(ctx, e.ctxFuncs, funcs)
+	}
+	funcs = sandboxFuncs(ctx, e.funcs, funcs)
+
+	t, err := e.instance(funcs)
+	if err != nil {
+		return fmt.Errorf("cannot render template: %w", err)
+	}
+
+	err = t.ExecuteTemplate(wr, block, data)
+	if err != nil && r.c.execErrorHandler != nil {
+		err = r.c.execErrorHandler(name, err)
+	}
 	if err != nil {
 		return fmt.Errorf("cannot render template: %w", err)
 	}
 	return nil
 }
+
+// PatchSubTemplate replaces a single sub-template within a top-level template.
+//
+// The topLevel parameter specifies the top-level template containing the
+// sub-template. The subName parameter specifies the name of the sub-template
+// to replace, and the text parameter provides its new definition. The
+// top-level template is cloned before parsing, so the original remains in use
+// if parsing fails.
+//
+// Returns ErrUnknownTemplate if either template does not exist, or an error if
+// the new text cannot be parsed.
+func (r *renderer) PatchSubTemplate(topLevel, subName string, text string) error {
+	return r.update(func(m map[string]*entry) error {
+		e, ok := m[topLevel]
+		if !ok {
+			return ErrUnknownTemplate
+		}
+
+		t, err := e.base.Clone()
+		if err != nil {
+			return fmt.Errorf("cannot clone template: %w", err)
+		}
+
+		if t.Lookup(subName) == nil {
+			return ErrUnknownTemplate
+		}
+
+		t, err = t.New(subName).Parse(text)
+		if err != nil {
+			return err
+		}
+
+		patched, err := newEntry(t.Lookup(topLevel))
+		if err != nil {
+			return err
+		}
+		patched.funcs = e.funcs
+		patched.ctxFuncs = e.ctxFuncs
+		patched.annotations = e.annotations
+		patched.etag = patchedETag(e.etag, subName, text)
+
+		if e.sources != nil {
+			patched.sources = maps.Clone(e.sources)
+			patched.sources[subName] = text
+		}
+
+		m[topLevel] = patched
+		return nil
+	})
+}
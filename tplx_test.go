@@ -0,0 +1,205 @@
+package tplx
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+var pageFS = fstest.MapFS{
+	"base.html": {Data: []byte(`<main>{{block "content" .}}default{{end}}</main>`)},
+	"page.html": {Data: []byte(`{{template "base" .}}{{define "content"}}<h1>{{.}}</h1>{{end}}`)},
+}
+
+var pageSpec = Spec{"page": {
+	{Name: "base", Path: "base.html"},
+	{Name: "page", Path: "page.html"},
+}}
+
+func newPageRenderer(tb testing.TB) *renderer {
+	tb.Helper()
+	r, err := NewRenderer(pageFS, pageSpec, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return r.(*renderer)
+}
+
+func renderString(tb testing.TB, r Renderer, name string, data any) string {
+	tb.Helper()
+	var buf bytes.Buffer
+	if err := r.Render(&buf, name, data, nil); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestPatchSubTemplate(t *testing.T) {
+	r := newPageRenderer(t)
+	if got, want := renderString(t, r, "page", "Home"), "<main><h1>Home</h1></main>"; got != want {
+		t.Fatalf("before patch: got %q, want %q", got, want)
+	}
+
+	old, _ := r.lookup("page")
+	if err := r.PatchSubTemplate("page", "content", "<h2>{{.}}</h2>"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := renderString(t, r, "page", "Home"), "<main><h2>Home</h2></main>"; got != want {
+		t.Errorf("after patch: got %q, want %q", got, want)
+	}
+
+	// The patched entry is published as a new value; renders that loaded the
+	// previous one keep using it.
+	cur, _ := r.lookup("page")
+	if cur == old {
+		t.Fatal("patch modified the entry in place")
+	}
+	var buf bytes.Buffer
+	if err := old.base.ExecuteTemplate(&buf, "page", "Home"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "<main><h1>Home</h1></main>"; got != want {
+		t.Errorf("previous entry: got %q, want %q", got, want)
+	}
+	if cur.etag == old.etag {
+		t.Error("patch did not change the ETag")
+	}
+}
+
+func TestPatchSubTemplateErrors(t *testing.T) {
+	r := newPageRenderer(t)
+
+	if err := r.PatchSubTemplate("missing", "content", ""); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("unknown top-level template: got %v, want ErrUnknownTemplate", err)
+	}
+	if err := r.PatchSubTemplate("page", "missing", ""); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("unknown sub-template: got %v, want ErrUnknownTemplate", err)
+	}
+
+	old, _ := r.lookup("page")
+	if err := r.PatchSubTemplate("page", "content", "{{.Broken"); err == nil {
+		t.Error("invalid text: got no error")
+	}
+	if cur, _ := r.lookup("page"); cur != old {
+		t.Error("failed patch replaced the entry")
+	}
+	if got, want := renderString(t, r, "page", "Home"), "<main><h1>Home</h1></main>"; got != want {
+		t.Errorf("after failed patch: got %q, want %q", got, want)
+	}
+}
+
+// TestConcurrentRenderAndPatch renders while templates are patched and
+// reloaded, for the race detector.
+func TestConcurrentRenderAndPatch(t *testing.T) {
+	r := newPageRenderer(t)
+	valid := map[string]bool{
+		"<main><h1>x</h1></main>": true,
+		"<main><h2>x</h2></main>": true,
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				var buf bytes.Buffer
+				if err := r.Render(&buf, "page", "x", nil); err != nil {
+					errs <- err
+					return
+				}
+				if !valid[buf.String()] {
+					errs <- errors.New("unexpected output " + buf.String())
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 100 {
+			text := "<h1>{{.}}</h1>"
+			if i%2 == 0 {
+				text = "<h2>{{.}}</h2>"
+			}
+			if err := r.PatchSubTemplate("page", "content", text); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 20 {
+			if err := r.Reload("page"); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func BenchmarkRender(b *testing.B) {
+	r := newPageRenderer(b)
+	b.ReportAllocs()
+	for range b.N {
+		if err := r.Render(io.Discard, "page", "Home", nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderParallel(b *testing.B) {
+	r := newPageRenderer(b)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := r.Render(io.Discard, "page", "Home", nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkRenderWhilePatching measures renders while another goroutine keeps
+// replacing the template map.
+func BenchmarkRenderWhilePatching(b *testing.B) {
+	r := newPageRenderer(b)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = r.PatchSubTemplate("page", "content", "<h1>{{.}}</h1>")
+			}
+		}
+	}()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := r.Render(io.Discard, "page", "Home", nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.StopTimer()
+	close(stop)
+	<-done
+}
@@ -0,0 +1,74 @@
+// Package tplxbench provides helpers for benchmarking templates rendered with
+// tplx.
+package tplxbench
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"runtime"
+	"runtime/pprof"
+	"testing"
+
+	"forgejo.helveticanonstandard.net/helvetica/tplx"
+)
+
+// BenchCase describes a single render to benchmark. Name names the
+// sub-benchmark, and TemplateName, Data and Funcs are passed to Render.
+type BenchCase struct {
+	Name         string
+	TemplateName string
+	Data         any
+	Funcs        template.FuncMap
+}
+
+// BenchmarkRenderer runs each case as a sub-benchmark of b, rendering to
+// io.Discard. Allocations are reported, so the results include B/op and
+// allocs/op in addition to ns/op. A case whose render fails stops its
+// sub-benchmark with an error.
+func BenchmarkRenderer(b *testing.B, r tplx.Renderer, cases []BenchCase) {
+	b.Helper()
+
+	for _, c := range cases {
+		b.Run(c.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				if err := r.Render(io.Discard, c.TemplateName, c.Data, c.Funcs); err != nil {
+					b.Fatalf("cannot render template %q: %v", c.TemplateName, err)
+				}
+			}
+		})
+	}
+}
+
+// MemProfileRenderer renders the case c once using r with memory profiling
+// enabled for every allocation, and returns the resulting allocation profile
+// in the gzipped protocol buffer format understood by go tool pprof.
+//
+// The allocs profile is cumulative: it includes every sampled allocation since
+// the process started, not only those of the render, and allocations made
+// before the call were sampled at the previous rate. To isolate the render,
+// write the allocs profile to a file right before the call and pass it to go
+// tool pprof with -base, or call MemProfileRenderer from a process that does
+// little else, such as a dedicated test. The Name of c is not used.
+//
+// Returns an error if the template cannot be rendered or the profile cannot
+// be written.
+func MemProfileRenderer(r tplx.Renderer, c BenchCase) (profileData []byte, err error) {
+	rate := runtime.MemProfileRate
+	runtime.MemProfileRate = 1
+	defer func() { runtime.MemProfileRate = rate }()
+
+	runtime.GC()
+	if err := r.Render(io.Discard, c.TemplateName, c.Data, c.Funcs); err != nil {
+		return nil, err
+	}
+	runtime.GC()
+
+	var buf bytes.Buffer
+	if err := pprof.Lookup("allocs").WriteTo(&buf, 0); err != nil {
+		return nil, fmt.Errorf("cannot write memory profile: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,59 @@
+// Package tplxchi integrates tplx with the go-chi/chi router.
+//
+// Middleware has the signature expected by chi's Router.Use, so a renderer can
+// be made available to all handlers of a router:
+//
+//	r := chi.NewRouter()
+//	r.Use(tplxchi.Middleware(renderer))
+//	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
+//		tplxchi.Render(req.Context(), w, "home", nil)
+//	})
+package tplxchi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"forgejo.helveticanonstandard.net/helvetica/tplx"
+)
+
+// ErrNoRenderer is returned when no renderer is stored in the context.
+var ErrNoRenderer = errors.New("no renderer in context")
+
+// Middleware returns a middleware that stores r, the request itself and its
+// response writer in the context of each request.
+func Middleware(r tplx.Renderer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx := tplx.NewResponseContext(tplx.NewRequestContext(tplx.NewContext(req.Context(), r), req), w)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
+
+// Renderer returns the renderer stored in ctx by Middleware, or nil if there
+// is none.
+func Renderer(ctx context.Context) tplx.Renderer {
+	r, _ := tplx.FromContext(ctx)
+	return r
+}
+
+// Render renders the named template with the renderer stored in ctx and
+// writes it to w. The Content-Type header is set to HTML unless it has already
+// been set.
+//
+// Returns ErrNoRenderer if ctx carries no renderer, or an error if the template
+// cannot be rendered.
+func Render(ctx context.Context, w http.ResponseWriter, name string, data any) error {
+	r := Renderer(ctx)
+	if r == nil {
+		return ErrNoRenderer
+	}
+
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+
+	return tplx.RenderContext(ctx, r, w, name, data, nil)
+}
@@ -0,0 +1,83 @@
+// Package tplxecho integrates tplx with the Echo web framework.
+package tplxecho
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"forgejo.helveticanonstandard.net/helvetica/tplx"
+	"github.com/labstack/echo/v4"
+)
+
+type adapter struct {
+	r tplx.Renderer
+}
+
+// Adapter converts r into an echo.Renderer.
+//
+// Templates are rendered with the context of the current request, which also
+// carries the request, its response writer and the renderer itself, so that context-aware options
+// and renderers such as tplx.RendererRouter work as expected.
+func Adapter(r tplx.Renderer) echo.Renderer {
+	return adapter{r: r}
+}
+
+func (a adapter) Render(w io.Writer, name string, data any, c echo.Context) error {
+	ctx := tplx.NewRequestContext(tplx.NewContext(c.Request().Context(), a.r), c.Request())
+	ctx = tplx.NewResponseContext(ctx, c.Response())
+	return tplx.RenderContext(ctx, a.r, w, name, data, nil)
+}
+
+// ErrorData is the data passed to error page templates.
+type ErrorData struct {
+	Code    int
+	Message string
+}
+
+// RegisterGlobals sets r as the renderer of e and installs an HTTP error
+// handler that renders error pages from templates.
+//
+// For an error with status code 404, the error handler renders the template
+// named "404" if it exists and the template named "error" otherwise, passing
+// ErrorData. If neither exists or r cannot report which templates it has, the
+// previous error handler of e is used.
+func RegisterGlobals(e *echo.Echo, r tplx.Renderer) {
+	e.Renderer = Adapter(r)
+
+	has, ok := r.(interface{ Has(string) bool })
+	if !ok {
+		return
+	}
+
+	fallback := e.HTTPErrorHandler
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		code := http.StatusInternalServerError
+		message := http.StatusText(code)
+		var he *echo.HTTPError
+		if errors.As(err, &he) {
+			code = he.Code
+			message = http.StatusText(code)
+			if m, ok := he.Message.(string); ok {
+				message = m
+			}
+		}
+
+		for _, name := range []string{strconv.Itoa(code), "error"} {
+			if has.Has(name) {
+				data := ErrorData{Code: code, Message: message}
+				if err := c.Render(code, name, data); err == nil {
+					return
+				}
+				break
+			}
+		}
+
+		fallback(err, c)
+	}
+}
@@ -0,0 +1,57 @@
+// Package tplxgin integrates tplx with the Gin web framework.
+//
+// Assigning the adapter to a gin.Engine makes c.HTML render through tplx:
+//
+//	engine := gin.New()
+//	engine.HTMLRender = tplxgin.GinAdapter(renderer)
+//	engine.GET("/", func(c *gin.Context) {
+//		c.HTML(http.StatusOK, "home", data)
+//	})
+package tplxgin
+
+import (
+	"context"
+	"net/http"
+
+	"forgejo.helveticanonstandard.net/helvetica/tplx"
+	"github.com/gin-gonic/gin/render"
+)
+
+var htmlContentType = []string{"text/html; charset=utf-8"}
+
+type htmlRender struct {
+	r tplx.Renderer
+}
+
+// GinAdapter converts r into a render.HTMLRender that can be assigned to
+// gin.Engine.HTMLRender.
+func GinAdapter(r tplx.Renderer) render.HTMLRender {
+	return htmlRender{r: r}
+}
+
+// Instance returns a render.Render for the named template and data.
+func (h htmlRender) Instance(name string, data any) render.Render {
+	return instance{r: h.r, name: name, data: data}
+}
+
+type instance struct {
+	r    tplx.Renderer
+	name string
+	data any
+}
+
+// Render renders the template to w. The render context carries w, so that
+// options can set response headers even if the output is buffered.
+func (i instance) Render(w http.ResponseWriter) error {
+	i.WriteContentType(w)
+	return tplx.RenderContext(tplx.NewResponseContext(context.Background(), w), i.r, w, i.name, i.data, nil)
+}
+
+// WriteContentType sets the Content-Type header to HTML unless it has already
+// been set.
+func (i instance) WriteContentType(w http.ResponseWriter) {
+	header := w.Header()
+	if val := header["Content-Type"]; len(val) == 0 {
+		header["Content-Type"] = htmlContentType
+	}
+}
@@ -0,0 +1,73 @@
+package tplxgin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"forgejo.helveticanonstandard.net/helvetica/tplx"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGinAdapter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fsys := fstest.MapFS{"home.html": {Data: []byte("<h1>{{.Title}}</h1>")}}
+	r, err := tplx.NewRenderer(fsys, tplx.Spec{"home": {{Name: "home", Path: "home.html"}}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	c, engine := gin.CreateTestContext(rec)
+	engine.HTMLRender = GinAdapter(r)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	c.HTML(http.StatusCreated, "home", map[string]string{"Title": "<Home>"})
+
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if got, want := rec.Header().Get("Content-Type"), "text/html; charset=utf-8"; got != want {
+		t.Errorf("Content-Type = %q, want %q", got, want)
+	}
+	if got, want := rec.Body.String(), "<h1>&lt;Home&gt;</h1>"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestGinAdapterKeepsContentType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fsys := fstest.MapFS{"feed.html": {Data: []byte("<feed/>")}}
+	r, err := tplx.NewRenderer(fsys, tplx.Spec{"feed": {{Name: "feed", Path: "feed.html"}}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := gin.New()
+	engine.HTMLRender = GinAdapter(r)
+	engine.GET("/feed", func(c *gin.Context) {
+		c.Header("Content-Type", "application/atom+xml")
+		c.HTML(http.StatusOK, "feed", nil)
+	})
+	engine.GET("/missing", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "missing", nil)
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed", nil))
+	if got, want := rec.Header().Get("Content-Type"), "application/atom+xml"; got != want {
+		t.Errorf("Content-Type = %q, want %q", got, want)
+	}
+	if got, want := rec.Body.String(), "<feed/>"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if rec.Body.Len() != 0 {
+		t.Errorf("unknown template: body = %q, want empty", rec.Body.String())
+	}
+}
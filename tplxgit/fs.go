@@ -0,0 +1,137 @@
+package tplxgit
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+type treeFS struct {
+	tree *object.Tree
+}
+
+// NewTreeFS returns an fs.FS presenting the contents of a git tree. Since git
+// does not record modification times, all files report the zero time.
+func NewTreeFS(tree *object.Tree) fs.FS {
+	return treeFS{tree: tree}
+}
+
+func (t treeFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name == "." {
+		return &dir{info: fileInfo{name: ".", mode: fs.ModeDir | 0o755}, tree: t.tree}, nil
+	}
+
+	e, err := t.tree.FindEntry(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if e.Mode == filemode.Dir {
+		sub, err := t.tree.Tree(name)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &dir{info: fileInfo{name: path.Base(name), mode: fs.ModeDir | 0o755}, tree: sub}, nil
+	}
+
+	f, err := t.tree.TreeEntryFile(e)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	r, err := f.Reader()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+
+	info := fileInfo{name: path.Base(name), size: f.Size, mode: fileMode(e.Mode)}
+	return &file{Reader: bytes.NewReader(data), info: info}, nil
+}
+
+func fileMode(m filemode.FileMode) fs.FileMode {
+	switch m {
+	case filemode.Dir:
+		return fs.ModeDir | 0o755
+	case filemode.Executable:
+		return 0o755
+	case filemode.Symlink:
+		return fs.ModeSymlink | 0o777
+	case filemode.Submodule:
+		return fs.ModeIrregular
+	default:
+		return 0o644
+	}
+}
+
+type fileInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi fileInfo) ModTime() time.Time { return time.Time{} }
+func (fi fileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi fileInfo) Sys() any           { return nil }
+
+type file struct {
+	*bytes.Reader
+	info fileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *file) Close() error               { return nil }
+
+type dir struct {
+	info fileInfo
+	tree *object.Tree
+	pos  int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dir) Close() error               { return nil }
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.tree.Entries[d.pos:]
+	if n > 0 {
+		if len(rest) == 0 {
+			return nil, io.EOF
+		}
+		rest = rest[:min(n, len(rest))]
+	}
+
+	entries := make([]fs.DirEntry, 0, len(rest))
+	for i := range rest {
+		e := &rest[i]
+		info := fileInfo{name: e.Name, mode: fileMode(e.Mode)}
+		if e.Mode.IsFile() {
+			if f, err := d.tree.TreeEntryFile(e); err == nil {
+				info.size = f.Size
+			}
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	d.pos += len(rest)
+	return entries, nil
+}
@@ -0,0 +1,94 @@
+// Package tplxgit loads tplx templates from git repositories using go-git.
+//
+// Repositories are cloned into memory, so no working copy is written to disk.
+// Pointing a renderer at a different ref is enough to swap its templates.
+package tplxgit
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+
+	"forgejo.helveticanonstandard.net/helvetica/tplx"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// NewRendererFromGit creates a new Renderer from the files of a git
+// repository at the given ref.
+//
+// The repoURL parameter specifies the repository to clone, and the ref
+// parameter specifies a branch, a tag, or a commit hash. An empty ref selects
+// the default branch. The remaining parameters are the same as for
+// tplx.NewRenderer.
+//
+// Returns a Renderer instance or an error if the repository cannot be loaded
+// or the templates cannot be initialized according to the specification.
+func NewRendererFromGit(repoURL, ref string, spec tplx.Spec, funcs template.FuncMap, opts ...tplx.Option) (tplx.Renderer, error) {
+	fsys, err := NewFS(repoURL, ref)
+	if err != nil {
+		return nil, err
+	}
+	return tplx.NewRenderer(fsys, spec, funcs, opts...)
+}
+
+// NewFS clones the repository at repoURL into memory and returns the tree of
+// the given ref as an fs.FS.
+//
+// Branches and tags are fetched with a shallow clone of only that ref, while a
+// commit hash requires the full history to be fetched.
+func NewFS(repoURL, ref string) (fs.FS, error) {
+	repo, err := clone(repoURL, ref)
+	if err != nil {
+		return nil, fmt.Errorf("unable to clone repository: %w", err)
+	}
+
+	rev := plumbing.Revision(ref)
+	if ref == "" {
+		rev = plumbing.Revision(plumbing.HEAD)
+	}
+
+	hash, err := repo.ResolveRevision(rev)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve ref %q: %w", ref, err)
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load commit: %w", err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load tree: %w", err)
+	}
+
+	return NewTreeFS(tree), nil
+}
+
+func clone(repoURL, ref string) (*git.Repository, error) {
+	if ref == "" || plumbing.IsHash(ref) {
+		return git.Clone(memory.NewStorage(), nil, &git.CloneOptions{URL: repoURL})
+	}
+
+	names := []plumbing.ReferenceName{
+		plumbing.NewBranchReferenceName(ref),
+		plumbing.NewTagReferenceName(ref),
+	}
+
+	var err error
+	for _, name := range names {
+		var repo *git.Repository
+		repo, err = git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
+			URL:           repoURL,
+			ReferenceName: name,
+			SingleBranch:  true,
+			Depth:         1,
+		})
+		if err == nil {
+			return repo, nil
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,154 @@
+// Package tplxhttp integrates tplx with net/http.
+//
+// Middleware makes a renderer available to all handlers, which can then
+// respond with Respond. Handler serves a template directly, and ErrorHandler
+// renders error pages:
+//
+//	mux := http.NewServeMux()
+//	mux.Handle("GET /{$}", tplxhttp.Handler(renderer, "home", homeData))
+//	mux.HandleFunc("GET /about", func(w http.ResponseWriter, req *http.Request) {
+//		if err := tplxhttp.Respond(w, req, "about", nil); err != nil {
+//			tplxhttp.ErrorHandler(renderer, "error")(w, req, http.StatusInternalServerError, err)
+//		}
+//	})
+//	http.ListenAndServe(":8080", tplxhttp.Middleware(renderer)(mux))
+//
+// All functions render into a buffer before writing the response, so a
+// failing render never sends partial output and the error can still be
+// answered with an error page.
+package tplxhttp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+
+	"forgejo.helveticanonstandard.net/helvetica/tplx"
+)
+
+// ErrNoRenderer is returned when no renderer is stored in the context.
+var ErrNoRenderer = errors.New("no renderer in context")
+
+// DataFunc returns the data for rendering a template in response to req.
+type DataFunc func(req *http.Request) (any, error)
+
+// StatusError is an error that carries the HTTP status code to respond with.
+// A DataFunc can return it, e.g. with http.StatusNotFound, to select the
+// status of the error response of Handler.
+type StatusError struct {
+	Code int
+	Err  error
+}
+
+func (e StatusError) Error() string {
+	return e.Err.Error()
+}
+
+func (e StatusError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorData is the data passed to error templates by ErrorHandler.
+type ErrorData struct {
+	Code    int
+	Message string
+	Err     error
+}
+
+// Middleware returns a middleware that stores r, the request itself and its
+// response writer in the context of each request.
+func Middleware(r tplx.Renderer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx := tplx.NewResponseContext(tplx.NewRequestContext(tplx.NewContext(req.Context(), r), req), w)
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
+
+// FromContext returns the renderer stored in ctx by Middleware, or nil if
+// there is none.
+func FromContext(ctx context.Context) tplx.Renderer {
+	r, _ := tplx.FromContext(ctx)
+	return r
+}
+
+// Handler returns a handler that renders the template name using r, with the
+// data returned by dataFn for each request. If dataFn is nil, the template is
+// rendered with nil data.
+//
+// If dataFn or the render fails, the error is answered with a plain error
+// response, using the status code of a StatusError and
+// http.StatusInternalServerError otherwise. Use ErrorHandler to render error
+// pages instead.
+func Handler(r tplx.Renderer, name string, dataFn DataFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var data any
+		if dataFn != nil {
+			var err error
+			if data, err = dataFn(req); err != nil {
+				code := statusCode(err)
+				http.Error(w, http.StatusText(code), code)
+				return
+			}
+		}
+
+		if err := respond(w, req, r, http.StatusOK, name, data); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	})
+}
+
+// ErrorHandler returns a function that answers a request with the error page
+// rendered by the template name using r, with ErrorData as its data. If the
+// error page cannot be rendered, a plain error response is sent instead.
+func ErrorHandler(r tplx.Renderer, name string) func(w http.ResponseWriter, req *http.Request, code int, err error) {
+	return func(w http.ResponseWriter, req *http.Request, code int, err error) {
+		data := ErrorData{Code: code, Message: http.StatusText(code), Err: err}
+		if respond(w, req, r, code, name, data) != nil {
+			http.Error(w, http.StatusText(code), code)
+		}
+	}
+}
+
+// Respond renders the named template with the renderer stored in the context
+// of req by Middleware and writes it to w with status http.StatusOK. The
+// Content-Type header is set to HTML unless it has already been set.
+//
+// Returns ErrNoRenderer if the context carries no renderer, or an error if the
+// template cannot be rendered, in which case nothing has been written to w.
+func Respond(w http.ResponseWriter, req *http.Request, name string, data any) error {
+	r := FromContext(req.Context())
+	if r == nil {
+		return ErrNoRenderer
+	}
+	return respond(w, req, r, http.StatusOK, name, data)
+}
+
+// respond renders the named template using r and writes it to w with the
+// given status code, unless rendering fails. Errors writing the response are
+// ignored, as the client is gone at that point, like in tplx.RenderResult.
+func respond(w http.ResponseWriter, req *http.Request, r tplx.Renderer, code int, name string, data any) error {
+	var buf bytes.Buffer
+	ctx := tplx.NewResponseContext(tplx.NewRequestContext(req.Context(), req), w)
+	if err := tplx.RenderContext(ctx, r, &buf, name, data, nil); err != nil {
+		return err
+	}
+
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	w.WriteHeader(code)
+	_, _ = buf.WriteTo(w)
+	return nil
+}
+
+// statusCode returns the status code to respond with for err.
+func statusCode(err error) int {
+	var se StatusError
+	if errors.As(err, &se) && se.Code != 0 {
+		return se.Code
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,230 @@
+package tplxs3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FSOption configures an fs.FS created by NewS3FS.
+type FSOption func(*s3FS)
+
+// WithCacheTTL keeps the contents of objects in memory for ttl after they
+// were fetched, so that reloading templates, e.g. with tplx.WithHotReload,
+// does not fetch every object again. Objects changed in the bucket are picked
+// up once their cache entry has expired. A ttl of 0 or less disables caching,
+// which is the default.
+func WithCacheTTL(ttl time.Duration) FSOption {
+	return func(s *s3FS) {
+		s.ttl = ttl
+	}
+}
+
+type s3FS struct {
+	client S3Client
+	bucket string
+	prefix string
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedObject
+}
+
+type cachedObject struct {
+	data    []byte
+	fetched time.Time
+}
+
+// NewS3FS returns an fs.FS presenting the objects in bucket whose keys start
+// with prefix, with the prefix removed and "/" separating directories. An
+// empty prefix presents the whole bucket. Since object storage has no
+// directories, a directory exists whenever an object is stored below it, and
+// all files report the zero modification time.
+func NewS3FS(client S3Client, bucket, prefix string, opts ...FSOption) fs.FS {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	s := &s3FS{client: client, bucket: bucket, prefix: prefix, cache: make(map[string]cachedObject)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *s3FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name != "." {
+		data, err := s.get(name)
+		if err == nil {
+			info := fileInfo{name: path.Base(name), size: int64(len(data)), mode: 0o644}
+			return &file{Reader: bytes.NewReader(data), info: info}, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+	}
+
+	entries, err := s.list(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if len(entries) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &dir{info: fileInfo{name: path.Base(name), mode: fs.ModeDir | 0o755}, entries: entries}, nil
+}
+
+// get returns the contents of the object for name, from the cache if
+// possible.
+func (s *s3FS) get(name string) ([]byte, error) {
+	key := s.prefix + name
+
+	if s.ttl > 0 {
+		s.mu.Lock()
+		obj, ok := s.cache[key]
+		s.mu.Unlock()
+		if ok && time.Since(obj.fetched) < s.ttl {
+			return obj.data, nil
+		}
+	}
+
+	rc, err := s.client.GetObject(context.Background(), s.bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.ttl > 0 {
+		s.mu.Lock()
+		s.cache[key] = cachedObject{data: data, fetched: time.Now()}
+		s.mu.Unlock()
+	}
+	return data, nil
+}
+
+// list returns the entries of the directory name, sorted by name.
+func (s *s3FS) list(name string) ([]fs.DirEntry, error) {
+	prefix := s.prefix
+	if name != "." {
+		prefix += name + "/"
+	}
+
+	keys, err := s.client.ListObjects(context.Background(), s.bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var entries []fs.DirEntry
+	for _, key := range keys {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok || rest == "" {
+			continue
+		}
+
+		child, _, isDir := strings.Cut(rest, "/")
+		if child == "" || seen[child] {
+			continue
+		}
+		seen[child] = true
+
+		entries = append(entries, &dirEntry{s: s, name: path.Join(strings.TrimPrefix(prefix, s.prefix), child), isDir: isDir})
+	}
+
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+// dirEntry is an entry of a directory listing. Since listings do not include
+// sizes, the object of a file is fetched when its info is requested.
+type dirEntry struct {
+	s     *s3FS
+	name  string
+	isDir bool
+}
+
+func (e *dirEntry) Name() string { return path.Base(e.name) }
+func (e *dirEntry) IsDir() bool  { return e.isDir }
+
+func (e *dirEntry) Type() fs.FileMode {
+	if e.isDir {
+		return fs.ModeDir
+	}
+	return 0
+}
+
+func (e *dirEntry) Info() (fs.FileInfo, error) {
+	if e.isDir {
+		return fileInfo{name: e.Name(), mode: fs.ModeDir | 0o755}, nil
+	}
+
+	data, err := e.s.get(e.name)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo{name: e.Name(), size: int64(len(data)), mode: 0o644}, nil
+}
+
+type fileInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi fileInfo) ModTime() time.Time { return time.Time{} }
+func (fi fileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi fileInfo) Sys() any           { return nil }
+
+type file struct {
+	*bytes.Reader
+	info fileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *file) Close() error               { return nil }
+
+type dir struct {
+	info    fileInfo
+	entries []fs.DirEntry
+	pos     int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dir) Close() error               { return nil }
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.pos:]
+	if n > 0 {
+		if len(rest) == 0 {
+			return nil, io.EOF
+		}
+		rest = rest[:min(n, len(rest))]
+	}
+	d.pos += len(rest)
+	return rest, nil
+}
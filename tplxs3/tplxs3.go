@@ -0,0 +1,35 @@
+// Package tplxs3 loads tplx templates from S3 compatible object storage, such
+// as AWS S3, MinIO or Google Cloud Storage in interoperability mode.
+//
+// The package does not depend on any S3 SDK. Instead, a thin S3Client adapter
+// around the SDK in use is passed in, so the client's own configuration,
+// credentials and retries apply.
+package tplxs3
+
+import (
+	"context"
+	"html/template"
+	"io"
+
+	"forgejo.helveticanonstandard.net/helvetica/tplx"
+)
+
+// S3Client is the subset of an S3 client needed to read templates.
+//
+// GetObject returns the contents of the object key in bucket. It should
+// return an error wrapping fs.ErrNotExist if the object does not exist.
+// ListObjects returns the keys of all objects in bucket starting with prefix.
+type S3Client interface {
+	GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+	ListObjects(ctx context.Context, bucket, prefix string) ([]string, error)
+}
+
+// NewRendererFromS3 creates a new Renderer from the objects in bucket whose
+// keys start with prefix, using NewS3FS without caching. The remaining
+// parameters are the same as for tplx.NewRenderer.
+//
+// Returns a Renderer instance or an error if the templates cannot be loaded
+// or initialized according to the specification.
+func NewRendererFromS3(client S3Client, bucket, prefix string, spec tplx.Spec, funcs template.FuncMap, opts ...tplx.Option) (tplx.Renderer, error) {
+	return tplx.NewRenderer(NewS3FS(client, bucket, prefix), spec, funcs, opts...)
+}
@@ -0,0 +1,161 @@
+package tplxtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"reflect"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"forgejo.helveticanonstandard.net/helvetica/tplx"
+)
+
+// FuzzRenderer sets up f to render the named template using r with randomly
+// generated data, to find panics in template functions or templates that
+// cannot cope with unexpected data such as missing fields or nil values.
+//
+// Each fuzz input is decoded as a JSON object if possible, and otherwise used
+// to generate a map[string]any of nested maps, slices, strings, numbers,
+// booleans and nil values. The JSON encoding of seed is added to the corpus,
+// so it should be a value resembling the data the template is normally
+// rendered with.
+//
+// Render errors are expected for random data and are ignored. A panic fails
+// the fuzz test, which makes the input part of the corpus of findings. Since
+// templates turn panics in functions into errors, only runtime errors such as
+// nil pointer dereferences are told apart from ordinary errors. Passing the
+// functions the renderer was created with as funcs detects any panic in
+// them, as they are wrapped to record panics and passed to each render.
+func FuzzRenderer(f *testing.F, r tplx.Renderer, name string, seed any, funcs ...template.FuncMap) {
+	f.Helper()
+
+	b, err := json.Marshal(seed)
+	if err != nil {
+		f.Fatalf("cannot encode seed data: %v", err)
+	}
+	f.Add(b)
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		var data map[string]any
+		if err := json.Unmarshal(input, &data); err != nil {
+			g := generator{b: input}
+			data = g.object(0)
+		}
+
+		var panics []string
+		wrapped := make(template.FuncMap)
+		for _, fm := range funcs {
+			for fname, fn := range fm {
+				wrapped[fname] = recordPanics(fname, fn, &panics)
+			}
+		}
+
+		defer func() {
+			if p := recover(); p != nil {
+				t.Fatalf("rendering template %q panicked: %v", name, p)
+			}
+		}()
+		err := r.Render(io.Discard, name, data, wrapped)
+		if len(panics) > 0 {
+			t.Fatalf("rendering template %q panicked: %s", name, panics[0])
+		}
+		var rerr runtime.Error
+		if errors.As(err, &rerr) {
+			t.Fatalf("rendering template %q panicked: %v", name, err)
+		}
+	})
+}
+
+// recordPanics wraps the template function fn so that panics are appended to
+// panics before being passed on.
+func recordPanics(name string, fn any, panics *[]string) any {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return fn
+	}
+	return reflect.MakeFunc(v.Type(), func(args []reflect.Value) []reflect.Value {
+		defer func() {
+			if p := recover(); p != nil {
+				*panics = append(*panics, fmt.Sprintf("function %q: %v", name, p))
+				panic(p)
+			}
+		}()
+		if v.Type().IsVariadic() {
+			return v.CallSlice(args)
+		}
+		return v.Call(args)
+	}).Interface()
+}
+
+// maxDepth limits the nesting of generated values.
+const maxDepth = 4
+
+// generator derives values from the bytes of a fuzz input. Once the input is
+// exhausted, it produces zero bytes.
+type generator struct {
+	b []byte
+}
+
+func (g *generator) byte() byte {
+	if len(g.b) == 0 {
+		return 0
+	}
+	c := g.b[0]
+	g.b = g.b[1:]
+	return c
+}
+
+func (g *generator) string() string {
+	n := int(g.byte() % 16)
+	s := make([]byte, 0, n)
+	for range n {
+		s = append(s, g.byte())
+	}
+	return string(s)
+}
+
+func (g *generator) object(depth int) map[string]any {
+	n := int(g.byte() % 8)
+	m := make(map[string]any, n)
+	for i := range n {
+		key := g.string()
+		if key == "" {
+			key = "k" + strconv.Itoa(i)
+		}
+		m[key] = g.value(depth + 1)
+	}
+	return m
+}
+
+func (g *generator) value(depth int) any {
+	kind := g.byte() % 8
+	if depth >= maxDepth && kind >= 6 {
+		kind = 0
+	}
+
+	switch kind {
+	case 0:
+		return nil
+	case 1:
+		return g.byte()%2 == 1
+	case 2:
+		return int(int8(g.byte()))
+	case 3:
+		return float64(int8(g.byte())) / 4
+	case 4, 5:
+		return g.string()
+	case 6:
+		n := int(g.byte() % 8)
+		s := make([]any, n)
+		for i := range s {
+			s[i] = g.value(depth + 1)
+		}
+		return s
+	default:
+		return g.object(depth)
+	}
+}
@@ -0,0 +1,166 @@
+package tplxtest
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"forgejo.helveticanonstandard.net/helvetica/tplx"
+	"golang.org/x/net/html"
+)
+
+// DOMRule is a single requirement on the structure of a rendered HTML
+// document. Check returns a message for each way in which doc violates the
+// rule, or nothing if it is satisfied.
+type DOMRule interface {
+	Check(doc *html.Node) []string
+}
+
+// SchemaViolation is a violation of a rule of a SchemaValidator.
+type SchemaViolation struct {
+	Rule    DOMRule
+	Message string
+}
+
+func (v SchemaViolation) String() string {
+	return v.Message
+}
+
+// SchemaValidator checks rendered HTML documents against a set of rules, such
+// as a template always containing an <h1> and exactly one
+// <form action="/submit">:
+//
+//	v := tplxtest.SchemaValidator{Rules: []tplxtest.DOMRule{
+//		tplxtest.RequireElement{Tag: "h1"},
+//		tplxtest.RequireElement{Tag: "form", Attrs: map[string]string{"action": "/submit"}, Count: 1},
+//	}}
+type SchemaValidator struct {
+	Rules []DOMRule
+}
+
+// Validate parses rendered as an HTML document and checks it against all
+// rules.
+//
+// Returns the violations in the order of the rules, or nil if the document
+// satisfies all of them.
+func (v SchemaValidator) Validate(rendered []byte) []SchemaViolation {
+	doc, err := html.Parse(bytes.NewReader(rendered))
+	if err != nil {
+		return []SchemaViolation{{Message: fmt.Sprintf("cannot parse HTML: %v", err)}}
+	}
+
+	var violations []SchemaViolation
+	for _, rule := range v.Rules {
+		for _, msg := range rule.Check(doc) {
+			violations = append(violations, SchemaViolation{Rule: rule, Message: msg})
+		}
+	}
+	return violations
+}
+
+// ValidateRender renders the named template using r and checks the output
+// against v. The test fails immediately if the template cannot be rendered,
+// and reports an error for each violation otherwise.
+func ValidateRender(t testing.TB, v SchemaValidator, r tplx.Renderer, name string, data any) {
+	t.Helper()
+
+	for _, violation := range v.Validate(RenderBytes(t, r, name, data)) {
+		t.Errorf("template %q: %s", name, violation)
+	}
+}
+
+// RequireElement requires elements with the tag Tag and, if Attrs is not
+// empty, the given attribute values. If Count is greater than 0, exactly
+// Count such elements are required, otherwise at least one.
+type RequireElement struct {
+	Tag   string
+	Attrs map[string]string
+	Count int
+}
+
+func (r RequireElement) Check(doc *html.Node) []string {
+	n := len(findElements(doc, r.Tag, r.Attrs))
+	switch {
+	case r.Count > 0 && n != r.Count:
+		return []string{fmt.Sprintf("expected %d %s, found %d", r.Count, describeElement(r.Tag, r.Attrs), n)}
+	case r.Count <= 0 && n == 0:
+		return []string{fmt.Sprintf("expected %s, found none", describeElement(r.Tag, r.Attrs))}
+	}
+	return nil
+}
+
+// RequireAttribute requires every element with the tag Tag to have the
+// attribute Attr, e.g. alt on every <img>.
+type RequireAttribute struct {
+	Tag  string
+	Attr string
+}
+
+func (r RequireAttribute) Check(doc *html.Node) []string {
+	var msgs []string
+	for _, n := range findElements(doc, r.Tag, nil) {
+		if _, ok := attr(n, r.Attr); !ok {
+			msgs = append(msgs, fmt.Sprintf("<%s> without %s attribute", r.Tag, r.Attr))
+		}
+	}
+	return msgs
+}
+
+// ForbidElement forbids elements with the tag Tag and, if Attrs is not
+// empty, the given attribute values.
+type ForbidElement struct {
+	Tag   string
+	Attrs map[string]string
+}
+
+func (r ForbidElement) Check(doc *html.Node) []string {
+	if n := len(findElements(doc, r.Tag, r.Attrs)); n > 0 {
+		return []string{fmt.Sprintf("expected no %s, found %d", describeElement(r.Tag, r.Attrs), n)}
+	}
+	return nil
+}
+
+// findElements returns the elements below n with the given tag and attribute
+// values, in document order.
+func findElements(n *html.Node, tag string, attrs map[string]string) []*html.Node {
+	var found []*html.Node
+	if n.Type == html.ElementNode && n.Data == tag && hasAttrs(n, attrs) {
+		found = append(found, n)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		found = append(found, findElements(c, tag, attrs)...)
+	}
+	return found
+}
+
+func hasAttrs(n *html.Node, attrs map[string]string) bool {
+	for key, val := range attrs {
+		if v, ok := attr(n, key); !ok || v != val {
+			return false
+		}
+	}
+	return true
+}
+
+func attr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// describeElement formats a tag and attributes as an HTML start tag.
+func describeElement(tag string, attrs map[string]string) string {
+	var sb strings.Builder
+	sb.WriteString("<" + tag)
+	for _, key := range slices.Sorted(maps.Keys(attrs)) {
+		fmt.Fprintf(&sb, " %s=%q", key, attrs[key])
+	}
+	sb.WriteString(">")
+	return sb.String()
+}
@@ -0,0 +1,28 @@
+// Package tplxtest provides helpers for testing code that renders templates
+// with tplx.
+package tplxtest
+
+import (
+	"bytes"
+	"testing"
+
+	"forgejo.helveticanonstandard.net/helvetica/tplx"
+)
+
+// Render renders the named template using r and returns the output. The test
+// fails immediately if the template cannot be rendered.
+func Render(t testing.TB, r tplx.Renderer, name string, data any) string {
+	t.Helper()
+	return string(RenderBytes(t, r, name, data))
+}
+
+// RenderBytes is like Render but returns the output as a byte slice.
+func RenderBytes(t testing.TB, r tplx.Renderer, name string, data any) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := r.Render(&buf, name, data, nil); err != nil {
+		t.Fatalf("cannot render template %q: %v", name, err)
+	}
+	return buf.Bytes()
+}
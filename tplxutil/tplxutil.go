@@ -0,0 +1,98 @@
+// Package tplxutil provides utilities for template functions used with tplx.
+package tplxutil
+
+import (
+	"html/template"
+	"sync"
+)
+
+// ConcurrentMap is a map that is safe for concurrent use, for state shared by
+// template functions across renders, such as hit counters or first-render
+// flags. The zero value is ready to use.
+type ConcurrentMap[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  map[K]V
+}
+
+// NewConcurrentMap creates an empty ConcurrentMap.
+func NewConcurrentMap[K comparable, V any]() *ConcurrentMap[K, V] {
+	return &ConcurrentMap[K, V]{}
+}
+
+// Get returns the value stored under key and whether it exists.
+func (cm *ConcurrentMap[K, V]) Get(key K) (V, bool) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	v, ok := cm.m[key]
+	return v, ok
+}
+
+// Set stores value under key.
+func (cm *ConcurrentMap[K, V]) Set(key K, value V) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if cm.m == nil {
+		cm.m = make(map[K]V)
+	}
+	cm.m[key] = value
+}
+
+// GetOrSet returns the value stored under key if it exists. Otherwise it
+// stores value and returns it. The loaded result reports whether the value
+// was already present.
+func (cm *ConcurrentMap[K, V]) GetOrSet(key K, value V) (actual V, loaded bool) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if v, ok := cm.m[key]; ok {
+		return v, true
+	}
+	if cm.m == nil {
+		cm.m = make(map[K]V)
+	}
+	cm.m[key] = value
+	return value, false
+}
+
+// Update replaces the value stored under key by the result of fn, which is
+// called with the current value and whether it exists, and returns the new
+// value. The map is locked while fn runs, so fn must not access the map, but
+// another goroutine cannot change the value in between, as it could between
+// Get and Set. This makes Update suitable for counters:
+//
+//	hits := m.Update("home", func(n int, _ bool) int { return n + 1 })
+func (cm *ConcurrentMap[K, V]) Update(key K, fn func(value V, ok bool) V) V {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if cm.m == nil {
+		cm.m = make(map[K]V)
+	}
+	v, ok := cm.m[key]
+	v = fn(v, ok)
+	cm.m[key] = v
+	return v
+}
+
+// Delete removes the value stored under key, if any.
+func (cm *ConcurrentMap[K, V]) Delete(key K) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	delete(cm.m, key)
+}
+
+// FuncMapFromConcurrentMap returns template functions accessing m:
+//
+//   - mapGet(key string) any returns the value stored under key, or nil.
+//   - mapSet(key string, value any) string stores value under key. It returns
+//     an empty string, so that it can be used as an action without output.
+func FuncMapFromConcurrentMap(m *ConcurrentMap[string, any]) template.FuncMap {
+	return template.FuncMap{
+		"mapGet": func(key string) any {
+			v, _ := m.Get(key)
+			return v
+		},
+		"mapSet": func(key string, value any) string {
+			m.Set(key, value)
+			return ""
+		},
+	}
+}
@@ -0,0 +1,60 @@
+package tplxutil
+
+import (
+	"bytes"
+	"html/template"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// TestConcurrentMap accesses the map from many goroutines, for the race
+// detector, and checks that no update is lost.
+func TestConcurrentMap(t *testing.T) {
+	const goroutines, rounds = 8, 500
+	m := NewConcurrentMap[string, int]()
+
+	var wg sync.WaitGroup
+	for i := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			own := strconv.Itoa(i)
+			for j := range rounds {
+				m.Update("hits", func(n int, _ bool) int { return n + 1 })
+				m.Set(own, j)
+				if v, ok := m.Get(own); !ok || v != j {
+					t.Errorf("%s: got %d, %v, want %d", own, v, ok, j)
+					return
+				}
+				m.GetOrSet("first", i)
+				_, _ = m.Get("hits")
+			}
+			m.Delete(own)
+		}()
+	}
+	wg.Wait()
+
+	if got, _ := m.Get("hits"); got != goroutines*rounds {
+		t.Errorf("hits: got %d, want %d", got, goroutines*rounds)
+	}
+	if _, ok := m.Get("0"); ok {
+		t.Error("deleted key still present")
+	}
+	if v, loaded := m.GetOrSet("first", -1); !loaded || v < 0 {
+		t.Errorf("GetOrSet: got %d, %v, want the first value stored", v, loaded)
+	}
+}
+
+func TestFuncMapFromConcurrentMap(t *testing.T) {
+	m := NewConcurrentMap[string, any]()
+	tmpl := template.Must(template.New("t").Funcs(FuncMapFromConcurrentMap(m)).Parse(`{{mapSet "k" .}}{{mapGet "k"}}|{{mapGet "missing"}}`))
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, "v"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "v|"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
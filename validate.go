@@ -0,0 +1,53 @@
+package tplx
+
+import "fmt"
+
+// ValidateSpec checks a Spec for mistakes that can be detected without
+// reading any template files.
+//
+// Returns an error wrapping ErrInvalidSpec if a fragment name occurs more than
+// once within the same top-level template, since the later fragment would
+// silently replace the earlier one, or if a fragment has a Format other than
+// "", "html" or "text". Fragments are compared regardless of their Format,
+// because NewRenderer parses all of them with html/template.
+func ValidateSpec(spec Spec) error {
+	return validateSpec(spec, false)
+}
+
+// validateSpec implements ValidateSpec. If byFormat is set, fragments of
+// different formats may share a name, as the HTML and plain-text versions
+// built by NewEmailRenderer do.
+func validateSpec(spec Spec, byFormat bool) error {
+	type key struct {
+		format string
+		name   string
+	}
+
+	for name, metas := range spec {
+		seen := make(map[key]string, len(metas))
+		for _, meta := range metas {
+			if meta.Extends != "" {
+				continue
+			}
+
+			format := meta.Format
+			switch format {
+			case "", FormatHTML:
+				format = FormatHTML
+			case FormatText:
+			default:
+				return fmt.Errorf("%w: fragment %q of template %q has unknown format %q", ErrInvalidSpec, meta.Name, name, meta.Format)
+			}
+
+			k := key{name: meta.Name}
+			if byFormat {
+				k.format = format
+			}
+			if path, ok := seen[k]; ok {
+				return fmt.Errorf("%w: fragment %q of template %q is defined by both %q and %q", ErrInvalidSpec, meta.Name, name, path, meta.Path)
+			}
+			seen[k] = meta.Path
+		}
+	}
+	return nil
+}
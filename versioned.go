@@ -0,0 +1,126 @@
+package tplx
+
+import (
+	"cmp"
+	"html/template"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// VersionedFuncMap holds several versions of template functions, so that
+// templates written against an older signature keep working after a function
+// changes. The zero value is ready to use.
+//
+// Each version of a function is available to templates under the function
+// name followed by an underscore and the version, e.g. format_v1 and
+// format_v2, and the plain name refers to the latest version. Characters of
+// the version that cannot appear in a template function name are replaced by
+// underscores, so version v1.2 of format is called as format_v1_2.
+type VersionedFuncMap struct {
+	funcs      map[string]map[string]any
+	deprecated map[string]map[string]bool
+}
+
+// NewVersionedFuncMap creates an empty VersionedFuncMap.
+func NewVersionedFuncMap() *VersionedFuncMap {
+	return &VersionedFuncMap{}
+}
+
+// Register adds version of the function name. Versions are of the form v1,
+// v2 or v1.2, and are ordered numerically to determine the latest one.
+// Registering an existing version replaces it.
+func (vfm *VersionedFuncMap) Register(name, version string, fn any) *VersionedFuncMap {
+	if vfm.funcs == nil {
+		vfm.funcs = make(map[string]map[string]any)
+	}
+	if vfm.funcs[name] == nil {
+		vfm.funcs[name] = make(map[string]any)
+	}
+	vfm.funcs[name][version] = fn
+	return vfm
+}
+
+// DeprecateVersion marks version of the function name as deprecated. Calls of
+// a deprecated version log a warning naming the latest version as its
+// replacement, as described for DeprecateFuncs.
+func (vfm *VersionedFuncMap) DeprecateVersion(name, version string) *VersionedFuncMap {
+	if vfm.deprecated == nil {
+		vfm.deprecated = make(map[string]map[string]bool)
+	}
+	if vfm.deprecated[name] == nil {
+		vfm.deprecated[name] = make(map[string]bool)
+	}
+	vfm.deprecated[name][version] = true
+	return vfm
+}
+
+// FuncMap returns the template functions for all registered versions.
+func (vfm *VersionedFuncMap) FuncMap() template.FuncMap {
+	funcs := make(template.FuncMap)
+	deprecated := make(map[string]DeprecatedFunc)
+
+	for name, versions := range vfm.funcs {
+		sorted := slices.SortedFunc(maps.Keys(versions), compareVersions)
+		latest := sorted[len(sorted)-1]
+
+		for _, version := range sorted {
+			vname := versionedName(name, version)
+			if vfm.deprecated[name][version] {
+				deprecated[vname] = DeprecatedFunc{Replacement: versionedName(name, latest), Fn: versions[version]}
+			} else {
+				funcs[vname] = versions[version]
+			}
+		}
+
+		if vfm.deprecated[name][latest] {
+			deprecated[name] = DeprecatedFunc{Fn: versions[latest]}
+		} else {
+			funcs[name] = versions[latest]
+		}
+	}
+
+	maps.Copy(funcs, DeprecateFuncs(deprecated))
+	return funcs
+}
+
+// WithVersionedFuncs registers all versions of the functions of vfm as
+// global template functions. Versions registered or deprecated after the
+// renderer has been created are not taken into account.
+func WithVersionedFuncs(vfm *VersionedFuncMap) Option {
+	return func(c *config) {
+		c.addFuncs(vfm.FuncMap())
+	}
+}
+
+// versionedName returns the template function name of version of the
+// function name, replacing characters that are not allowed in identifiers by
+// underscores.
+func versionedName(name, version string) string {
+	return name + "_" + strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, version)
+}
+
+// compareVersions orders versions such as v1 and v1.2 numerically, falling
+// back to comparing them as strings if they are not numeric.
+func compareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := range min(len(pa), len(pb)) {
+		na, erra := strconv.Atoi(pa[i])
+		nb, errb := strconv.Atoi(pb[i])
+		if erra != nil || errb != nil {
+			return cmp.Compare(a, b)
+		}
+		if c := cmp.Compare(na, nb); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(pa), len(pb))
+}
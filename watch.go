@@ -0,0 +1,140 @@
+package tplx
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchFS watches the given files for changes using fsnotify and reloads the
+// affected top-level templates of r.
+//
+// The paths parameter specifies operating system paths of the template files.
+// A changed file affects the templates that depend on a template path which
+// the changed path ends with, so that e.g. "web/templates/home.html" matches
+// the template path "home.html" of a renderer reading from os.DirFS("web/templates").
+// Since editors often save files in several steps, events are collected until
+// no further event arrives for the debounce duration before reloading.
+//
+// The parent directories of all paths are watched rather than the files
+// themselves, so that files replaced by renaming are still picked up. Reload
+// errors, which are expected while a file is being edited, are logged with
+// slog.Default.
+//
+// WatchFS blocks until ctx is done, in which case it returns nil, or until
+// watching fails.
+func WatchFS(ctx context.Context, r ReloadableRenderer, paths []string, debounce time.Duration) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	files := make(map[string]bool, len(paths))
+	dirs := make(map[string]bool)
+	for _, p := range paths {
+		p = filepath.Clean(p)
+		files[p] = true
+		dirs[filepath.Dir(p)] = true
+	}
+
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			return err
+		}
+	}
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			name := filepath.Clean(event.Name)
+			if !files[name] || event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			for _, tp := range r.Paths() {
+				if matchesTemplatePath(name, tp) {
+					for _, dep := range r.Dependents(tp) {
+						pending[dep] = true
+					}
+				}
+			}
+
+			if len(pending) > 0 {
+				timer.Reset(debounce)
+			}
+
+		case <-timer.C:
+			names := slices.Sorted(maps.Keys(pending))
+			clear(pending)
+
+			if err := r.Reload(names...); err != nil {
+				slog.Default().ErrorContext(ctx, "cannot reload templates", "templates", names, "error", err)
+			}
+		}
+	}
+}
+
+// WatchAll is like WatchFS but watches all template files of r.
+//
+// If r reads from a directory created by os.DirFS, the template paths are
+// resolved relative to that directory. Otherwise they are taken to be relative
+// to the working directory.
+func WatchAll(ctx context.Context, r ReloadableRenderer, debounce time.Duration) error {
+	root := "."
+	if rr, ok := r.(*renderer); ok {
+		if dir, ok := dirFSRoot(rr.fsys); ok {
+			root = dir
+		}
+	}
+
+	var paths []string
+	for _, p := range r.Paths() {
+		paths = append(paths, filepath.Join(root, filepath.FromSlash(p)))
+	}
+
+	return WatchFS(ctx, r, paths, debounce)
+}
+
+// matchesTemplatePath reports whether the operating system path name refers to
+// the file at the slash-separated template path tp.
+func matchesTemplatePath(name, tp string) bool {
+	name = filepath.ToSlash(name)
+	return name == tp || strings.HasSuffix(name, "/"+tp)
+}
+
+// dirFSRoot returns the directory of a file system created by os.DirFS.
+func dirFSRoot(fsys fs.FS) (string, bool) {
+	v := reflect.ValueOf(fsys)
+	if !v.IsValid() || v.Type() != reflect.TypeOf(os.DirFS(".")) || v.Kind() != reflect.String {
+		return "", false
+	}
+	return v.String(), true
+}
@@ -0,0 +1,196 @@
+package tplx
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"maps"
+	"mime"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+)
+
+// WithXHTMLFallback serves XHTML to clients preferring it. The templates of
+// xhtmlSpec are read from the file system of the renderer and parsed with
+// text/template, as the contextual escaping of html/template does not apply
+// to XML. They are parsed once when the renderer is created and are not
+// affected by Reload.
+//
+// The output of every action is escaped for XML text and attribute values by
+// the template function xmlEscape, which is applied automatically. Values of
+// type template.HTML, and values already escaped by an explicit call of
+// xmlEscape, are written as is. Unlike html/template, the escaping does not
+// depend on the context of an action: values inside script and style
+// elements are not escaped for JavaScript or CSS, and values in URL
+// attributes such as href are not checked for schemes like javascript:, so
+// such values must be trusted or sanitized by the template functions
+// producing them.
+//
+// When a template is rendered to an http.ResponseWriter, or with one in the
+// context (see NewResponseContext), the request is taken from the context
+// (see NewRequestContext). If its Accept header prefers application/xhtml+xml
+// over text/html and xhtmlSpec has a template of the same name, that template
+// is rendered with the Content-Type application/xhtml+xml. Otherwise the HTML
+// template is rendered with the Content-Type text/html. Responses negotiated
+// between both versions get the header Vary: Accept. A Content-Type set
+// before the render is kept, and the XHTML template is then only rendered if
+// it is application/xhtml+xml.
+func WithXHTMLFallback(xhtmlSpec Spec) Option {
+	return func(c *config) {
+		c.xhtmlSpec = xhtmlSpec
+	}
+}
+
+func parseXHTML(c *config, fsys fs.FS, spec Spec, funcs template.FuncMap) (*textRenderer, error) {
+	if err := ValidateSpec(spec); err != nil {
+		return nil, err
+	}
+
+	funcs = maps.Clone(funcs)
+	if funcs == nil {
+		funcs = make(template.FuncMap)
+	}
+	funcs["xmlEscape"] = xmlEscape
+
+	r := &textRenderer{m: make(map[string]*texttemplate.Template, len(spec))}
+	for name := range spec {
+		t, err := parseText(c, fsys, spec, name, texttemplate.FuncMap(funcs))
+		if err != nil {
+			return nil, fmt.Errorf("XHTML version of %q: %w", name, err)
+		}
+
+		// The escaper is added last so that it cannot be replaced by a
+		// function of the same name.
+		t.Funcs(texttemplate.FuncMap{"xmlEscape": xmlEscape})
+		for _, tt := range t.Templates() {
+			if tt.Tree != nil {
+				escapeXMLActions(tt.Tree.Root)
+			}
+		}
+		r.m[name] = t
+	}
+	return r, nil
+}
+
+// escapedXML is text escaped by xmlEscape.
+type escapedXML string
+
+// xmlEscape formats its arguments like fmt.Sprint and escapes the result for
+// XML text and attribute values. A single argument of type escapedXML or
+// template.HTML is returned unchanged, so applying it to its own output does
+// not escape twice.
+func xmlEscape(args ...any) escapedXML {
+	if len(args) == 1 {
+		switch v := args[0].(type) {
+		case nil:
+			return ""
+		case escapedXML:
+			return v
+		case template.HTML:
+			return escapedXML(v)
+		}
+	}
+
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(fmt.Sprint(args...)))
+	return escapedXML(b.String())
+}
+
+// escapeXMLActions appends a call of xmlEscape to the pipeline of every action
+// below n that produces output.
+func escapeXMLActions(n parse.Node) {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			escapeXMLActions(c)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) > 0 {
+			return
+		}
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      n.Pos,
+			Args:     []parse.Node{parse.NewIdentifier("xmlEscape").SetPos(n.Pos)},
+		})
+	case *parse.IfNode:
+		escapeXMLActions(n.List)
+		escapeXMLActions(n.ElseList)
+	case *parse.RangeNode:
+		escapeXMLActions(n.List)
+		escapeXMLActions(n.ElseList)
+	case *parse.WithNode:
+		escapeXMLActions(n.List)
+		escapeXMLActions(n.ElseList)
+	}
+}
+
+type xhtmlKey struct{}
+
+func xhtmlFromContext(ctx context.Context) bool {
+	v, _ := ctx.Value(xhtmlKey{}).(bool)
+	return v
+}
+
+// negotiateXHTML decides whether the template name is rendered as XHTML to w,
+// sets the Content-Type header for it unless it is already set, and returns a
+// context recording the decision.
+func (r *renderer) negotiateXHTML(ctx context.Context, w io.Writer, name string) context.Context {
+	if r.xhtml == nil {
+		return ctx
+	}
+
+	rw, ok := responseWriter(ctx, w)
+	if !ok {
+		return ctx
+	}
+
+	if ct := rw.Header().Get("Content-Type"); ct != "" {
+		if mt, _, _ := mime.ParseMediaType(ct); mt == "application/xhtml+xml" {
+			if _, exists := r.xhtml.m[name]; exists {
+				return context.WithValue(ctx, xhtmlKey{}, true)
+			}
+		}
+		return ctx
+	}
+
+	req, ok := RequestFromContext(ctx)
+	if _, exists := r.xhtml.m[name]; ok && exists {
+		rw.Header().Add("Vary", "Accept")
+		accept := req.Header.Get("Accept")
+		if acceptQuality(accept, "application/xhtml+xml") > acceptQuality(accept, "text/html") {
+			rw.Header().Set("Content-Type", "application/xhtml+xml; charset=utf-8")
+			return context.WithValue(ctx, xhtmlKey{}, true)
+		}
+	}
+
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	return ctx
+}
+
+// acceptQuality returns the quality value the Accept header value header
+// assigns to mediaType, or 0 if the header does not list it. Wildcards are
+// not taken into account.
+func acceptQuality(header, mediaType string) float64 {
+	for _, part := range strings.Split(header, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mt != mediaType {
+			continue
+		}
+
+		q, err := strconv.ParseFloat(params["q"], 64)
+		if err != nil {
+			return 1
+		}
+		return q
+	}
+	return 0
+}
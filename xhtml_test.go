@@ -0,0 +1,153 @@
+package tplx
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func newXHTMLRenderer(tb testing.TB) Renderer {
+	tb.Helper()
+	fsys := fstest.MapFS{
+		"page.html":  {Data: []byte(`<p>{{.}}</p>`)},
+		"page.xhtml": {Data: []byte(`<p title="{{.}}">{{.}}</p>`)},
+		"raw.xhtml":  {Data: []byte(`<div>{{raw}}{{xmlEscape .}}</div>`)},
+		"raw.html":   {Data: []byte(`<div></div>`)},
+	}
+	spec := Spec{
+		"page": {{Name: "page", Path: "page.html"}},
+		"raw":  {{Name: "raw", Path: "raw.html"}},
+	}
+	xhtmlSpec := Spec{
+		"page": {{Name: "page", Path: "page.xhtml"}},
+		"raw":  {{Name: "raw", Path: "raw.xhtml"}},
+	}
+	funcs := template.FuncMap{"raw": func() template.HTML { return "<br/>" }}
+	r, err := NewRenderer(fsys, spec, funcs, WithXHTMLFallback(xhtmlSpec))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return r
+}
+
+func TestXHTMLEscaping(t *testing.T) {
+	r := newXHTMLRenderer(t)
+
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{name: "text and attribute", tmpl: "page", want: `<p title="&lt;a&amp;&#34;b&gt;">&lt;a&amp;&#34;b&gt;</p>`},
+		{name: "trusted HTML and explicit escape", tmpl: "raw", want: `<div><br/>&lt;a&amp;&#34;b&gt;</div>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept", "application/xhtml+xml")
+			rec := httptest.NewRecorder()
+			if err := RenderContext(NewRequestContext(context.Background(), req), r, rec, tt.tmpl, `<a&"b>`, nil); err != nil {
+				t.Fatal(err)
+			}
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXHTMLNegotiation(t *testing.T) {
+	r := newXHTMLRenderer(t)
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		wrap        bool
+		wantType    string
+		wantBody    string
+	}{
+		{name: "html", accept: "text/html", wantType: "text/html; charset=utf-8", wantBody: "<p>x</p>"},
+		{name: "xhtml", accept: "application/xhtml+xml", wantType: "application/xhtml+xml; charset=utf-8", wantBody: `<p title="x">x</p>`},
+		{
+			name:     "html preferred by quality",
+			accept:   "application/xhtml+xml;q=0.8, text/html",
+			wantType: "text/html; charset=utf-8",
+			wantBody: "<p>x</p>",
+		},
+		{
+			name:     "writer from context",
+			accept:   "application/xhtml+xml",
+			wrap:     true,
+			wantType: "application/xhtml+xml; charset=utf-8",
+			wantBody: `<p title="x">x</p>`,
+		},
+		{
+			name:        "preset content type",
+			accept:      "application/xhtml+xml",
+			contentType: "text/html",
+			wantType:    "text/html",
+			wantBody:    "<p>x</p>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept", tt.accept)
+			rec := httptest.NewRecorder()
+			if tt.contentType != "" {
+				rec.Header().Set("Content-Type", tt.contentType)
+			}
+
+			ctx := NewResponseContext(NewRequestContext(context.Background(), req), rec)
+			var err error
+			if tt.wrap {
+				var buf bytes.Buffer
+				err = RenderContext(ctx, r, &buf, "page", "x", nil)
+				rec.Body = &buf
+			} else {
+				err = RenderContext(ctx, r, rec, "page", "x", nil)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := rec.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type: got %q, want %q", got, tt.wantType)
+			}
+			if tt.contentType == "" {
+				if got := rec.Header().Get("Vary"); got != "Accept" {
+					t.Errorf("Vary: got %q, want Accept", got)
+				}
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("body: got %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestXHTMLDelims(t *testing.T) {
+	fsys := fstest.MapFS{
+		"page.html":  {Data: []byte(`<p>[[.]]</p>`)},
+		"page.xhtml": {Data: []byte(`<p xmlns="http://www.w3.org/1999/xhtml">[[.]]</p>`)},
+	}
+	r, err := NewRenderer(fsys, Spec{"page": {{Name: "page", Path: "page.html"}}}, nil,
+		WithDelims("[[", "]]"), WithXHTMLFallback(Spec{"page": {{Name: "page", Path: "page.xhtml"}}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/xhtml+xml")
+	rec := httptest.NewRecorder()
+	if err := RenderContext(NewRequestContext(context.Background(), req), r, rec, "page", "x", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rec.Body.String(), `<p xmlns="http://www.w3.org/1999/xhtml">x</p>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}